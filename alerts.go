@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type AlertProvider string
+
+const (
+	AlertProviderPagerDuty AlertProvider = "pagerduty"
+	AlertProviderOpsgenie  AlertProvider = "opsgenie"
+)
+
+const (
+	PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	OpsgenieAlertsURL  = "https://api.opsgenie.com/v2/alerts"
+
+	// Opsgenie rejects alerts with longer messages.
+	OpsgenieMaxMessageLength = 130
+)
+
+func parseAlertProvider(value string) AlertProvider {
+	provider := AlertProvider(value)
+
+	switch provider {
+	case AlertProviderPagerDuty, AlertProviderOpsgenie:
+		return provider
+	default:
+		log.Fatal().Str("value", value).Msg("Invalid alert provider, expected pagerduty or opsgenie")
+		return ""
+	}
+}
+
+// AlertRule decides whether a report should page someone. All of the conditions that are set
+// should match, the message ones should match for at least one of the messages in a tx.
+type AlertRule struct {
+	Name         string   `mapstructure:"name"`
+	Severity     string   `mapstructure:"severity"`
+	Chains       []string `mapstructure:"chains"`
+	MsgTypes     []string `mapstructure:"msg-types"`
+	Validators   []string `mapstructure:"validators"`
+	MinAmount    float64  `mapstructure:"min-amount"`
+	KnownSigners []string `mapstructure:"known-signers"`
+}
+
+func loadAlertRules() []AlertRule {
+	var rules []AlertRule
+
+	if !viper.IsSet("alert-rules") {
+		return rules
+	}
+
+	if err := viper.UnmarshalKey("alert-rules", &rules); err != nil {
+		log.Fatal().Err(err).Msg("Could not parse alert rules config")
+	}
+
+	return rules
+}
+
+func validateAlertRules(rules []AlertRule) []AlertRule {
+	for index := range rules {
+		rule := &rules[index]
+
+		if rule.Name == "" {
+			log.Fatal().Int("index", index).Msg("Alert rule name is not set")
+		}
+
+		switch rule.Severity {
+		case "":
+			rule.Severity = "critical"
+		case "critical", "error", "warning", "info":
+		default:
+			log.Fatal().
+				Str("rule", rule.Name).
+				Str("severity", rule.Severity).
+				Msg("Invalid alert rule severity, expected critical, error, warning or info")
+		}
+	}
+
+	return rules
+}
+
+func (rule AlertRule) Matches(report Report) bool {
+	if len(rule.Chains) > 0 && !contains(rule.Chains, report.Chain.Name) {
+		return false
+	}
+
+	if len(rule.KnownSigners) > 0 {
+		unknownSignerFound := false
+		for _, signer := range report.Tx.Signers {
+			if !contains(rule.KnownSigners, signer) {
+				unknownSignerFound = true
+				break
+			}
+		}
+
+		if !unknownSignerFound {
+			return false
+		}
+	}
+
+	for _, msg := range report.Msgs {
+		if rule.MatchesMsg(msg, report.Chain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (rule AlertRule) MatchesMsg(msg Msg, chain *Chain) bool {
+	if len(rule.MsgTypes) > 0 && !contains(rule.MsgTypes, getMsgTypeName(msg)) {
+		return false
+	}
+
+	if len(rule.Validators) > 0 {
+		validatorFound := false
+		for _, validator := range getMsgValidators(msg) {
+			if contains(rule.Validators, validator) {
+				validatorFound = true
+				break
+			}
+		}
+
+		if !validatorFound {
+			return false
+		}
+	}
+
+	if rule.MinAmount > 0 {
+		amount, found := getMsgNativeAmount(msg, chain)
+		if !found || amount < rule.MinAmount {
+			return false
+		}
+	}
+
+	return true
+}
+
+type AlertReporter struct {
+	ReporterName    string
+	ReporterQueries []string
+	ReporterFilter  *Filter
+
+	AlertProvider      AlertProvider
+	AlertKey           string
+	AlertURL           string
+	AlertRules         []AlertRule
+	AlertFailedTxsMode FailedTxsMode
+
+	HttpClient      *http.Client
+	PlainSerializer Serializer
+}
+
+type PagerDutyEvent struct {
+	RoutingKey  string           `json:"routing_key"`
+	EventAction string           `json:"event_action"`
+	DedupKey    string           `json:"dedup_key"`
+	Payload     PagerDutyPayload `json:"payload"`
+	Links       []PagerDutyLink  `json:"links"`
+}
+
+type PagerDutyPayload struct {
+	Summary       string     `json:"summary"`
+	Source        string     `json:"source"`
+	Severity      string     `json:"severity"`
+	Timestamp     string     `json:"timestamp,omitempty"`
+	Component     string     `json:"component"`
+	Group         string     `json:"group"`
+	CustomDetails JSONReport `json:"custom_details"`
+}
+
+type PagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+type OpsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description"`
+	Priority    string            `json:"priority"`
+	Source      string            `json:"source"`
+	Tags        []string          `json:"tags"`
+	Details     map[string]string `json:"details"`
+}
+
+var opsgeniePriorities = map[string]string{
+	"critical": "P1",
+	"error":    "P2",
+	"warning":  "P3",
+	"info":     "P5",
+}
+
+func (r AlertReporter) Serialize(report Report) string {
+	var sb strings.Builder
+
+	serializer := r.PlainSerializer.withChain(report.Chain)
+
+	sb.WriteString(report.Tx.Serialize(serializer) + "\n\n")
+
+	for _, msg := range report.Msgs {
+		sb.WriteString(msg.Serialize(serializer) + "\n\n")
+	}
+
+	return sb.String()
+}
+
+func (r *AlertReporter) Init() {
+	if r.AlertKey == "" {
+		log.Debug().Msg("Alert key not set, not creating alert reporter.")
+		return
+	}
+
+	if len(r.AlertRules) == 0 {
+		log.Warn().Msg("Alert key is set, but there are no alert rules, not creating alert reporter.")
+		return
+	}
+
+	if r.AlertURL == "" {
+		if r.AlertProvider == AlertProviderOpsgenie {
+			r.AlertURL = OpsgenieAlertsURL
+		} else {
+			r.AlertURL = PagerDutyEventsURL
+		}
+	}
+
+	r.HttpClient = &http.Client{Timeout: 10 * time.Second}
+	r.PlainSerializer = NewPlainSerializer(TxFields{Fee: true, Signers: true, Time: true})
+}
+
+func (r AlertReporter) Enabled() bool {
+	return r.HttpClient != nil
+}
+
+func (r AlertReporter) Serializer() Serializer {
+	return r.PlainSerializer
+}
+
+// getDedupKey returns the key the incidents are deduplicated by, so the same tx
+// sent twice (like after a backfill) does not page twice.
+func getDedupKey(report Report) string {
+	return fmt.Sprintf("%s-%s", report.Chain.Name, report.Tx.Hash)
+}
+
+func (r AlertReporter) SendReport(report Report) error {
+	var matchedRule *AlertRule
+	for index, rule := range r.AlertRules {
+		if rule.Matches(report) {
+			matchedRule = &r.AlertRules[index]
+			break
+		}
+	}
+
+	if matchedRule == nil {
+		log.Debug().Str("hash", report.Tx.Hash).Msg("No alert rules matched, not sending alert.")
+		return nil
+	}
+
+	log.Info().
+		Str("hash", report.Tx.Hash).
+		Str("rule", matchedRule.Name).
+		Msg("Alert rule matched, sending alert")
+
+	summary := report.Summary()
+
+	if r.AlertProvider == AlertProviderOpsgenie {
+		return r.sendRequest(OpsgenieAlert{
+			Message:     truncateString(summary, OpsgenieMaxMessageLength-4),
+			Alias:       getDedupKey(report),
+			Description: r.Serialize(report),
+			Priority:    opsgeniePriorities[matchedRule.Severity],
+			Source:      "cosmos-transactions-bot",
+			Tags:        []string{report.Chain.Name, matchedRule.Name},
+			Details: map[string]string{
+				"chain":  report.Chain.Name,
+				"hash":   report.Tx.Hash,
+				"height": fmt.Sprintf("%d", report.Tx.Height),
+				"rule":   matchedRule.Name,
+				"link":   report.Chain.makeMintscanTxLink(report.Tx.Hash),
+			},
+		})
+	}
+
+	event := PagerDutyEvent{
+		RoutingKey:  r.AlertKey,
+		EventAction: "trigger",
+		DedupKey:    getDedupKey(report),
+		Payload: PagerDutyPayload{
+			Summary:       summary,
+			Source:        report.Chain.Name,
+			Severity:      matchedRule.Severity,
+			Component:     matchedRule.Name,
+			Group:         "cosmos-transactions-bot",
+			CustomDetails: report.SerializeJSON(),
+		},
+		Links: []PagerDutyLink{{
+			Href: report.Chain.makeMintscanTxLink(report.Tx.Hash),
+			Text: "Transaction on Mintscan",
+		}},
+	}
+
+	if !report.Tx.Timestamp.IsZero() {
+		event.Payload.Timestamp = report.Tx.Timestamp.Format(time.RFC3339)
+	}
+
+	return r.sendRequest(event)
+}
+
+func (r AlertReporter) sendRequest(payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, r.AlertURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	if r.AlertProvider == AlertProviderOpsgenie {
+		request.Header.Set("Authorization", "GenieKey "+r.AlertKey)
+	}
+
+	response, err := r.HttpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		responseBody, _ := ioutil.ReadAll(io.LimitReader(response.Body, 1024))
+		return fmt.Errorf("got unexpected status code %d: %s", response.StatusCode, string(responseBody))
+	}
+
+	return nil
+}
+
+func (r AlertReporter) Name() string {
+	return r.ReporterName
+}
+
+func (r AlertReporter) FailedTxsMode() FailedTxsMode {
+	return r.AlertFailedTxsMode
+}
+
+func (r AlertReporter) Queries() []string {
+	return r.ReporterQueries
+}
+
+func (r AlertReporter) Filter() *Filter {
+	return r.ReporterFilter
+}
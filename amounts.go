@@ -0,0 +1,114 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// SummaryPrecision is how many decimal digits are shown in the one-line summaries.
+const SummaryPrecision = 2
+
+// getDenomCoefficient returns the native denom coefficient, which is a decimal, so the amounts
+// are converted into display denom exactly, even for the tokens with 18 decimals.
+func (c *Chain) getDenomCoefficient() sdk.Dec {
+	if c.denomCoefficient.IsNil() || !c.denomCoefficient.IsPositive() {
+		return sdk.OneDec()
+	}
+
+	return c.denomCoefficient
+}
+
+// floatToDec converts the coefficients and prices that come as floats into decimals.
+// The digits after the 18th decimal one are dropped, as sdk.Dec can't have them.
+func floatToDec(value float64) (sdk.Dec, error) {
+	return sdk.NewDecFromStr(strconv.FormatFloat(value, 'f', sdk.Precision, 64))
+}
+
+// formatAmount formats the amount with the given number of decimal digits, rounded,
+// and with the thousands separated, like 1,234.567890.
+func formatAmount(amount sdk.Dec, precision int) string {
+	if precision < 0 {
+		precision = 0
+	}
+	if precision > sdk.Precision {
+		precision = sdk.Precision
+	}
+
+	rounded := amount.MulInt(sdk.NewIntWithDecimal(1, precision)).RoundInt()
+	digits := rounded.Abs().String()
+
+	if len(digits) <= precision {
+		digits = strings.Repeat("0", precision-len(digits)+1) + digits
+	}
+
+	integerPart := digits[:len(digits)-precision]
+	fractionalPart := digits[len(digits)-precision:]
+
+	var sb strings.Builder
+
+	if rounded.IsNegative() {
+		sb.WriteString("-")
+	}
+
+	for index, digit := range integerPart {
+		if index > 0 && (len(integerPart)-index)%3 == 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteRune(digit)
+	}
+
+	if precision > 0 {
+		sb.WriteString("." + fractionalPart)
+	}
+
+	return sb.String()
+}
+
+// formatAmountShort formats the amount like formatAmount, but without trailing zeros.
+func formatAmountShort(amount sdk.Dec, precision int) string {
+	formatted := formatAmount(amount, precision)
+	if !strings.Contains(formatted, ".") {
+		return formatted
+	}
+
+	return strings.TrimSuffix(strings.TrimRight(formatted, "0"), ".")
+}
+
+// fiatSymbols are the signs of the common currencies, the rest are shown by their code.
+var fiatSymbols = map[string]string{
+	"usd": "$",
+	"eur": "€",
+	"gbp": "£",
+	"jpy": "¥",
+	"cny": "¥",
+	"krw": "₩",
+	"inr": "₹",
+	"rub": "₽",
+}
+
+// FiatPrecision is how many decimal digits the fiat values are shown with.
+const FiatPrecision = 3
+
+// formatFiatValue formats the value in fiat currency, like $1,234.567 or 1,234.567 CHF.
+func formatFiatValue(value sdk.Dec, currency string) string {
+	if symbol, found := fiatSymbols[currency]; found {
+		return symbol + formatAmount(value, FiatPrecision)
+	}
+
+	return formatAmount(value, FiatPrecision) + " " + strings.ToUpper(currency)
+}
+
+// getFiatValue returns the amount multiplied by the price. The prices come as floats
+// from the providers, but the multiplication is done in decimals, so the big amounts
+// keep all their digits.
+func getFiatValue(amount sdk.Dec, rate float64) sdk.Dec {
+	price, err := floatToDec(rate)
+	if err != nil {
+		log.Warn().Err(err).Float64("price", rate).Msg("Invalid price, not showing fiat value")
+		return sdk.ZeroDec()
+	}
+
+	return amount.Mul(price)
+}
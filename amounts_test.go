@@ -0,0 +1,75 @@
+package main
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		amount    string
+		precision int
+		expected  string
+	}{
+		{"0", 2, "0.00"},
+		{"0", 0, "0"},
+		{"1", 6, "1.000000"},
+		{"0.4", 0, "0"},
+		{"0.6", 0, "1"},
+		{"0.000001", 6, "0.000001"},
+		{"0.0000004", 6, "0.000000"},
+		{"0.0000006", 6, "0.000001"},
+
+		// sdk.Dec rounds the halves to even
+		{"0.5", 0, "0"},
+		{"1.5", 0, "2"},
+		{"0.0000025", 6, "0.000002"},
+		{"1.999", 2, "2.00"},
+		{"1234.5678", 2, "1,234.57"},
+		{"123", 0, "123"},
+		{"1234567", 0, "1,234,567"},
+		{"1234567.891", 3, "1,234,567.891"},
+		{"-1234.5", 1, "-1,234.5"},
+		{"-0.001", 2, "0.00"},
+
+		// 18 decimals and big amounts keep all their digits
+		{"123456789012345678901234.123456789012345678", 18, "123,456,789,012,345,678,901,234.123456789012345678"},
+
+		// the precision is clamped to what sdk.Dec has
+		{"1.5", -1, "2"},
+		{"0.000000000000000001", 30, "0.000000000000000001"},
+	}
+
+	for _, test := range tests {
+		result := formatAmount(sdk.MustNewDecFromStr(test.amount), test.precision)
+		if result != test.expected {
+			t.Errorf("%s with precision %d: expected %q, got %q", test.amount, test.precision, test.expected, result)
+		}
+	}
+}
+
+func TestFormatAmountShort(t *testing.T) {
+	tests := []struct {
+		amount    string
+		precision int
+		expected  string
+	}{
+		{"0", 2, "0"},
+		{"1", 6, "1"},
+		{"1.5", 6, "1.5"},
+		{"1.50", 2, "1.5"},
+		{"1.999", 2, "2"},
+		{"1234.5678", 2, "1,234.57"},
+		{"1000", 0, "1,000"},
+		{"1000.001", 2, "1,000"},
+		{"-2.10", 2, "-2.1"},
+	}
+
+	for _, test := range tests {
+		result := formatAmountShort(sdk.MustNewDecFromStr(test.amount), test.precision)
+		if result != test.expected {
+			t.Errorf("%s with precision %d: expected %q, got %q", test.amount, test.precision, test.expected, result)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+)
+
+const TxSearchPerPage = 100
+
+func (c *Chain) initHttpClient() {
+	client, err := rpchttp.New(c.TendermintRpcAddress, "/websocket")
+	if err != nil {
+		log.Fatal().Err(err).Str("chain", c.Name).Msg("Failed to create an HTTP client")
+	}
+
+	c.HttpClient = client
+}
+
+func (c *Chain) getLatestHeight() (int64, error) {
+	status, err := c.HttpClient.Status(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	return status.SyncInfo.LatestBlockHeight, nil
+}
+
+// backfill processes the txs matching the queries that were committed since the given height,
+// which is the last processed one at the moment of (re)connecting, so the txs missed while
+// the bot was down or disconnected are sent. Txs that were already sent are skipped by generateReport.
+func (c *Chain) backfill(lastHeight int64) {
+	if lastHeight == 0 {
+		log.Info().Str("chain", c.Name).Msg("No last processed height, nothing to backfill.")
+		return
+	}
+
+	latestHeight, err := c.getLatestHeight()
+	if err != nil {
+		log.Error().Err(err).Str("chain", c.Name).Msg("Could not get latest height, not backfilling")
+		return
+	}
+
+	if err := c.processTxsInRange(c.getBackfillStartHeight(lastHeight, latestHeight), latestHeight); err != nil {
+		log.Error().Err(err).Str("chain", c.Name).Msg("Could not backfill txs")
+		return
+	}
+
+	stateManager.setLastHeight(c.Name, latestHeight)
+}
+
+// getBackfillStartHeight limits how far back the txs are searched. The last height only moves
+// when there are matching txs, so after a restart on a quiet chain it can be days behind,
+// and searching all of that can take forever, especially when all txs are matched locally.
+func (c *Chain) getBackfillStartHeight(lastHeight int64, latestHeight int64) int64 {
+	if c.MaxBackfillBlocks == nil {
+		return lastHeight
+	}
+
+	maxBlocks := *c.MaxBackfillBlocks
+	if maxBlocks <= 0 || latestHeight-lastHeight <= maxBlocks {
+		return lastHeight
+	}
+
+	log.Warn().
+		Str("chain", c.Name).
+		Int64("last-height", lastHeight).
+		Int64("latest-height", latestHeight).
+		Int64("max-backfill-blocks", maxBlocks).
+		Msg("Too many blocks since the last processed one, only searching the latest ones")
+
+	return latestHeight - maxBlocks
+}
+
+// processTxsInRange searches for txs matching each query between the given heights,
+// both inclusive, and sends them to reporters.
+func (c *Chain) processTxsInRange(fromHeight int64, toHeight int64) error {
+	log.Info().
+		Str("chain", c.Name).
+		Int64("from", fromHeight).
+		Int64("to", toHeight).
+		Msg("Processing txs in range")
+
+	subscriptions, _ := c.getSubscriptions()
+
+	for _, query := range subscriptions {
+		txResults, err := c.searchTxs(fmt.Sprintf(
+			"%s AND tx.height >= %d AND tx.height <= %d",
+			query,
+			fromHeight,
+			toHeight,
+		))
+		if err != nil {
+			return err
+		}
+
+		log.Debug().
+			Str("chain", c.Name).
+			Str("query", query).
+			Int("count", len(txResults)).
+			Msg("Found txs in range")
+
+		for _, txResult := range txResults {
+			c.sendReport(c.generateReportFromTxResult(txResult))
+		}
+	}
+
+	return nil
+}
+
+func (c *Chain) searchTxs(query string) ([]abciTypes.TxResult, error) {
+	txResults := []abciTypes.TxResult{}
+	perPage := TxSearchPerPage
+
+	for page := 1; ; page++ {
+		currentPage := page
+		result, err := c.HttpClient.TxSearch(context.Background(), query, false, &currentPage, &perPage, "asc")
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tx := range result.Txs {
+			txResults = append(txResults, abciTypes.TxResult{
+				Height: tx.Height,
+				Index:  tx.Index,
+				Tx:     tx.Tx,
+				Result: tx.TxResult,
+			})
+		}
+
+		if len(result.Txs) == 0 || len(txResults) >= result.TotalCount {
+			return txResults, nil
+		}
+	}
+}
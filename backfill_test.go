@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestGetBackfillStartHeight(t *testing.T) {
+	limit := int64(100)
+	noLimit := int64(0)
+
+	tests := []struct {
+		name         string
+		maxBlocks    *int64
+		lastHeight   int64
+		latestHeight int64
+		expected     int64
+	}{
+		{"not set", nil, 10, 1000, 10},
+		{"no limit", &noLimit, 10, 1000, 10},
+		{"within limit", &limit, 950, 1000, 950},
+		{"exactly at limit", &limit, 900, 1000, 900},
+		{"over limit", &limit, 10, 1000, 900},
+		{"caught up", &limit, 1000, 1000, 1000},
+	}
+
+	for _, test := range tests {
+		chain := &Chain{ChainConfig: ChainConfig{Name: "cosmos", MaxBackfillBlocks: test.maxBlocks}}
+
+		if result := chain.getBackfillStartHeight(test.lastHeight, test.latestHeight); result != test.expected {
+			t.Errorf("%s: expected %d, got %d", test.name, test.expected, result)
+		}
+	}
+}
@@ -5,70 +5,78 @@ import (
 	"strings"
 
 	cosmosTypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	cosmosBankTypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	"github.com/gogo/protobuf/proto"
 )
 
+func init() {
+	RegisterMsgParser("/cosmos.bank.v1beta1.MsgSend", ParseMsgSend)
+}
+
 type MsgSend struct {
 	FromAddress string
 	ToAddress   string
 	Coins       []Coin
 }
 
+// Coin is an amount in display denom. The base denom is kept to know which token it is,
+// as several tokens can have the same display denom.
 type Coin struct {
-	Amount float64
-	Denom  string
+	Amount    sdk.Dec
+	Denom     string
+	BaseDenom string
+	Channel   string
+}
+
+// DisplayDenom returns the denom with the channel the IBC token came through, if it's known.
+func (coin Coin) DisplayDenom() string {
+	if coin.Channel == "" {
+		return coin.Denom
+	}
+
+	return fmt.Sprintf("%s (via %s)", coin.Denom, coin.Channel)
 }
 
 func (msg MsgSend) Empty() bool {
 	return msg.FromAddress == ""
 }
 
-func ParseMsgSend(message *cosmosTypes.Any) MsgSend {
+func ParseMsgSend(message *cosmosTypes.Any, chain *Chain, block int64) Msg {
 	var parsedMessage cosmosBankTypes.MsgSend
 	if err := proto.Unmarshal(message.Value, &parsedMessage); err != nil {
 		log.Error().Err(err).Msg("Could not parse MsgSend")
 		return MsgSend{}
 	}
 
-	coins := []Coin{}
-
-	for _, coin := range parsedMessage.Amount {
-		log.Info().
-			Str("from", parsedMessage.FromAddress).
-			Str("to", parsedMessage.ToAddress).
-			Str("denom", Denom).
-			Float64("amount", float64(coin.Amount.Int64())/DenomCoefficient).
-			Msg("MsgSend")
-
-		coins = append(coins, Coin{
-			Amount: float64(coin.Amount.Int64()) / DenomCoefficient,
-			Denom:  Denom,
-		})
-	}
+	log.Info().
+		Str("from", parsedMessage.FromAddress).
+		Str("to", parsedMessage.ToAddress).
+		Str("amount", parsedMessage.Amount.String()).
+		Msg("MsgSend")
 
 	return MsgSend{
 		FromAddress: parsedMessage.FromAddress,
 		ToAddress:   parsedMessage.ToAddress,
-		Coins:       coins,
+		Coins:       chain.getCoins(parsedMessage.Amount),
 	}
 }
 
 func (msg MsgSend) Serialize(serializer Serializer) string {
-	fromLabel, fromLabelFound := labelsConfigManager.getWalletLabel(msg.FromAddress)
-	toLabel, toLabelFound := labelsConfigManager.getWalletLabel(msg.ToAddress)
+	fromLabel, fromLabelFound := serializer.Chain.LabelsConfigManager.getWalletLabel(msg.FromAddress)
+	toLabel, toLabelFound := serializer.Chain.LabelsConfigManager.getWalletLabel(msg.ToAddress)
 
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf("%s\n", serializer.StrongSerializer("Transfer")))
 
 	for _, coin := range msg.Coins {
-		sb.WriteString(serializer.getTokensMaybeWithDollarPrice(coin.Amount, coin.Denom) + "\n")
+		sb.WriteString(serializer.getCoinMaybeWithFiatPrice(coin) + "\n")
 	}
 
 	sb.WriteString(fmt.Sprintf(`%s %s`,
 		serializer.StrongSerializer("From:"),
-		serializer.LinksSerializer(makeMintscanAccountLink(msg.FromAddress), msg.FromAddress),
+		serializer.LinksSerializer(serializer.Chain.makeMintscanAccountLink(msg.FromAddress), msg.FromAddress),
 	))
 
 	if fromLabelFound {
@@ -82,7 +90,7 @@ func (msg MsgSend) Serialize(serializer Serializer) string {
 
 	sb.WriteString(fmt.Sprintf(`%s %s`,
 		serializer.StrongSerializer("To:"),
-		serializer.LinksSerializer(makeMintscanAccountLink(msg.ToAddress), msg.ToAddress),
+		serializer.LinksSerializer(serializer.Chain.makeMintscanAccountLink(msg.ToAddress), msg.ToAddress),
 	))
 
 	if toLabelFound {
@@ -94,3 +102,31 @@ func (msg MsgSend) Serialize(serializer Serializer) string {
 
 	return sb.String()
 }
+
+func (msg MsgSend) Summary(chain *Chain) string {
+	amounts := make([]string, len(msg.Coins))
+	for index, coin := range msg.Coins {
+		amounts[index] = getTokensSummary(coin.Amount, coin.Denom)
+	}
+
+	return fmt.Sprintf(
+		"Send %s from %s to %s",
+		strings.Join(amounts, ", "),
+		chain.getWalletSummary(msg.FromAddress),
+		chain.getWalletSummary(msg.ToAddress),
+	)
+}
+
+type JSONMsgSend struct {
+	From   JSONAddress  `json:"from"`
+	To     JSONAddress  `json:"to"`
+	Amount []JSONAmount `json:"amount"`
+}
+
+func (msg MsgSend) SerializeJSON(chain *Chain) interface{} {
+	return JSONMsgSend{
+		From:   chain.getJSONAddress(msg.FromAddress),
+		To:     chain.getJSONAddress(msg.ToAddress),
+		Amount: chain.getJSONCoins(msg.Coins),
+	}
+}
@@ -1,26 +1,41 @@
 package main
 
 import (
+	"sync"
+	"time"
+
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 )
 
 type Cache struct {
 	Validators map[string]stakingtypes.Validator
+
+	// denoms do not change, so they are not cleared with the rest of the cache
+	Denoms      map[string]DenomInfo
+	DenomsMutex sync.RWMutex
+
+	// the bank metadata loaded on start, by base denom, it's only written to once
+	DenomsMetadata map[string]DenomInfo
+
+	// all the txs of a block have the same time, so only the last block's one is kept
+	BlockHeight int64
+	BlockTime   time.Time
+	BlockMutex  sync.Mutex
 }
 
 type CacheManager struct {
-	Cache            Cache
-	GrpcWrapper      GrpcWrapper
-	CoingeckoWrapper CoingeckoWrapper
+	Cache       Cache
+	GrpcWrapper GrpcWrapper
 }
 
-func NewCacheManager(grpcWrapper *GrpcWrapper, coingeckoWrapper *CoingeckoWrapper) *CacheManager {
+func NewCacheManager(grpcWrapper *GrpcWrapper) *CacheManager {
 	return &CacheManager{
 		Cache: Cache{
-			Validators: make(map[string]stakingtypes.Validator),
+			Validators:     make(map[string]stakingtypes.Validator),
+			Denoms:         make(map[string]DenomInfo),
+			DenomsMetadata: make(map[string]DenomInfo),
 		},
-		GrpcWrapper:      *grpcWrapper,
-		CoingeckoWrapper: *coingeckoWrapper,
+		GrpcWrapper: *grpcWrapper,
 	}
 }
 
@@ -42,8 +57,38 @@ func (c *CacheManager) getValidatorMaybeFromCache(address string) (stakingtypes.
 	return validator, nil
 }
 
-func (c *CacheManager) getRate() (float64, error) {
-	return c.CoingeckoWrapper.GetRate()
+func (c *CacheManager) getDenomInfoFromCache(denom string) (DenomInfo, bool) {
+	c.Cache.DenomsMutex.RLock()
+	defer c.Cache.DenomsMutex.RUnlock()
+
+	info, found := c.Cache.Denoms[denom]
+	return info, found
+}
+
+func (c *CacheManager) setDenomInfo(denom string, info DenomInfo) {
+	c.Cache.DenomsMutex.Lock()
+	defer c.Cache.DenomsMutex.Unlock()
+
+	c.Cache.Denoms[denom] = info
+}
+
+func (c *CacheManager) getBlockTimeMaybeFromCache(height int64) (time.Time, error) {
+	c.Cache.BlockMutex.Lock()
+	defer c.Cache.BlockMutex.Unlock()
+
+	if c.Cache.BlockHeight == height {
+		log.Trace().Int64("height", height).Msg("Getting block time from cache")
+		return c.Cache.BlockTime, nil
+	}
+
+	blockTime, err := c.GrpcWrapper.getBlockTime(height)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	c.Cache.BlockHeight = height
+	c.Cache.BlockTime = blockTime
+	return blockTime, nil
 }
 
 func (c *CacheManager) clearCache() {
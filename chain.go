@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/spf13/viper"
+
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+	tmclient "github.com/tendermint/tendermint/rpc/jsonrpc/client"
+	jsonRpcTypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+)
+
+const DefaultQuery = "tx.height > 1"
+
+type ChainConfig struct {
+	Name                 string                       `mapstructure:"name"`
+	NodeAddress          string                       `mapstructure:"node"`
+	TendermintRpcAddress string                       `mapstructure:"tendermint-rpc"`
+	BaseDenom            string                       `mapstructure:"base-denom"`
+	Denom                string                       `mapstructure:"denom"`
+	DenomCoefficient     float64                      `mapstructure:"denom-coefficient"`
+	DisplayPrecision     int                          `mapstructure:"display-precision"`
+	MintscanProject      string                       `mapstructure:"mintscan-project"`
+	CoingeckoCurrency    string                       `mapstructure:"coingecko-currency"`
+	FiatCurrency         string                       `mapstructure:"fiat-currency"`
+	Prices               map[string]PriceSourceConfig `mapstructure:"prices"`
+	PricesFile           string                       `mapstructure:"prices-file"`
+	Queries              []string                     `mapstructure:"query"`
+	NamedQueries         map[string]string            `mapstructure:"named-queries"`
+	Filter               string                       `mapstructure:"filter"`
+	Watch                WatchConfig                  `mapstructure:"watch"`
+	MaxSubscriptions     int                          `mapstructure:"max-subscriptions"`
+	MaxBackfillBlocks    *int64                       `mapstructure:"max-backfill-blocks"`
+	LabelsConfigPath     string                       `mapstructure:"labels-config"`
+	AssetLists           []string                     `mapstructure:"assetlists"`
+}
+
+type Chain struct {
+	ChainConfig
+
+	Client              *tmclient.WSClient
+	HttpClient          *rpchttp.HTTP
+	GrpcWrapper         *GrpcWrapper
+	CacheManager        *CacheManager
+	LabelsConfigManager *LabelsConfigManager
+	TokenRegistry       *TokenRegistry
+	PriceManager        *PriceManager
+
+	queries       []NamedQuery
+	subscriptions []string
+	matchLocally  bool
+	queriesMutex  sync.RWMutex
+	filter        *Filter
+	// the native denom coefficient, from the config or from the bank metadata
+	denomCoefficient sdk.Dec
+	// the bech32 prefix of the accounts, to get the signers of the failed txs
+	accountPrefix string
+	reconnected   chan int64
+	responses     chan jsonRpcTypes.RPCResponse
+}
+
+// loadChainConfigs reads the chains list from the config file. If there is none,
+// a single chain is built out of the flat flags, so old configs keep working.
+func loadChainConfigs() []ChainConfig {
+	var configs []ChainConfig
+
+	if viper.IsSet("chains") {
+		if err := viper.UnmarshalKey("chains", &configs); err != nil {
+			log.Fatal().Err(err).Msg("Could not parse chains config")
+		}
+	}
+
+	if len(configs) == 0 {
+		log.Debug().Msg("No chains are configured, using a single chain from flags.")
+
+		var watch WatchConfig
+		if err := viper.UnmarshalKey("watch", &watch); err != nil {
+			log.Fatal().Err(err).Msg("Could not parse watch config")
+		}
+
+		var prices map[string]PriceSourceConfig
+		if err := viper.UnmarshalKey("prices", &prices); err != nil {
+			log.Fatal().Err(err).Msg("Could not parse prices config")
+		}
+
+		// the default query matches all txs, so it's dropped if there are named queries
+		// or watched addresses
+		namedQueries := viper.GetStringMapString("named-queries")
+		queries := Queries
+		if (len(namedQueries) > 0 || !watch.Empty()) && len(queries) == 1 && queries[0] == DefaultQuery {
+			queries = []string{}
+		}
+
+		return []ChainConfig{{
+			Name:                 MintscanProject,
+			NodeAddress:          NodeAddress,
+			TendermintRpcAddress: TendermintRpcAddress,
+			BaseDenom:            BaseDenom,
+			Denom:                Denom,
+			DenomCoefficient:     DenomCoefficient,
+			DisplayPrecision:     DisplayPrecision,
+			MintscanProject:      MintscanProject,
+			CoingeckoCurrency:    CoingeckoCurrency,
+			FiatCurrency:         FiatCurrency,
+			Prices:               prices,
+			PricesFile:           PricesFile,
+			Queries:              queries,
+			NamedQueries:         namedQueries,
+			Filter:               FilterExpression,
+			Watch:                watch,
+			MaxSubscriptions:     MaxSubscriptions,
+			MaxBackfillBlocks:    &MaxBackfillBlocks,
+			LabelsConfigPath:     LabelsConfigPath,
+			AssetLists:           AssetLists,
+		}}
+	}
+
+	names := make(map[string]bool)
+
+	for index := range configs {
+		config := &configs[index]
+
+		if config.Name == "" {
+			log.Fatal().Int("index", index).Msg("Chain name is not set")
+		}
+
+		if _, ok := names[config.Name]; ok {
+			log.Fatal().Str("name", config.Name).Msg("Chain name is used more than once")
+		}
+
+		names[config.Name] = true
+
+		// the same defaults as the flags have
+		if config.NodeAddress == "" {
+			config.NodeAddress = "localhost:9090"
+		}
+		if config.TendermintRpcAddress == "" {
+			config.TendermintRpcAddress = "tcp://localhost:26657"
+		}
+		if config.DenomCoefficient == 0 {
+			config.DenomCoefficient = 1_000_000
+		}
+		if config.DisplayPrecision == 0 {
+			config.DisplayPrecision = DisplayPrecision
+		}
+		if config.FiatCurrency == "" {
+			config.FiatCurrency = FiatCurrency
+		}
+		if config.PricesFile == "" {
+			config.PricesFile = PricesFile
+		}
+		if config.MintscanProject == "" {
+			config.MintscanProject = config.Name
+		}
+		if len(config.Queries) == 0 && len(config.NamedQueries) == 0 && config.Watch.Empty() {
+			config.Queries = []string{DefaultQuery}
+		}
+		if config.MaxSubscriptions == 0 {
+			config.MaxSubscriptions = MaxSubscriptions
+		}
+		// a pointer, as 0 means no limit here
+		if config.MaxBackfillBlocks == nil {
+			config.MaxBackfillBlocks = &MaxBackfillBlocks
+		}
+		if config.LabelsConfigPath == "" {
+			config.LabelsConfigPath = LabelsConfigPath
+		}
+		if len(config.AssetLists) == 0 {
+			config.AssetLists = AssetLists
+		}
+	}
+
+	return configs
+}
+
+func NewChain(config ChainConfig) *Chain {
+	config.FiatCurrency = strings.ToLower(config.FiatCurrency)
+
+	queries := append(getChainQueries(config), getRuntimeQueries(config.Name)...)
+	subscriptions, matchLocally := getSubscriptions(config.Name, queries, config.MaxSubscriptions)
+
+	return &Chain{
+		ChainConfig:   config,
+		queries:       queries,
+		subscriptions: subscriptions,
+		matchLocally:  matchLocally,
+		filter:        mustParseFilter(config.Filter, "chain", config.Name),
+		reconnected:   make(chan int64, 1),
+		responses:     make(chan jsonRpcTypes.RPCResponse),
+	}
+}
+
+func (c *Chain) Init() {
+	c.GrpcWrapper = InitGrpcWrapper(c.NodeAddress)
+
+	c.LabelsConfigManager = getLabelsConfigManager(c.LabelsConfigPath)
+	c.TokenRegistry = NewTokenRegistry(c.Name, c.AssetLists, c.GrpcWrapper)
+	c.CacheManager = NewCacheManager(c.GrpcWrapper)
+	c.loadDenoms()
+	c.PriceManager = NewPriceManager(c)
+
+	if prefix, err := c.GrpcWrapper.getAccountPrefix(); err != nil {
+		log.Warn().Err(err).Str("chain", c.Name).Msg("Could not get accounts prefix, failed txs would have no signers")
+	} else {
+		c.accountPrefix = prefix
+	}
+
+	log.Info().
+		Str("chain", c.Name).
+		Str("denom", c.Denom).
+		Str("node", c.NodeAddress).
+		Str("tendermint-rpc", c.TendermintRpcAddress).
+		Msg("Init chain")
+}
+
+func (c *Chain) Listen() {
+	c.initHttpClient()
+
+	if UsePolling {
+		c.poll()
+		return
+	}
+
+	if err := c.initWebsocketClient(); err != nil {
+		log.Warn().
+			Err(err).
+			Str("chain", c.Name).
+			Msg("Could not connect to websocket, falling back to polling")
+		c.poll()
+		return
+	}
+
+	lastHeight := stateManager.getLastHeight(c.Name)
+	go c.queueResponses()
+	c.subscribeToUpdates()
+	c.backfill(lastHeight)
+
+	for {
+		select {
+		case result := <-c.responses:
+			c.processResponse(result)
+		case lastHeight := <-c.reconnected:
+			c.backfill(lastHeight)
+		}
+	}
+}
+
+// queueResponses reads the websocket responses as soon as they come and keeps them
+// until they are processed, so the websocket is not blocked during long backfills,
+// which could make the node drop the connection.
+func (c *Chain) queueResponses() {
+	var queue []jsonRpcTypes.RPCResponse
+
+	for {
+		var responses chan jsonRpcTypes.RPCResponse
+		var next jsonRpcTypes.RPCResponse
+
+		// sending to a nil channel blocks, so nothing is sent while the queue is empty
+		if len(queue) > 0 {
+			responses = c.responses
+			next = queue[0]
+		}
+
+		select {
+		case result, ok := <-c.Client.ResponsesCh:
+			if !ok {
+				return
+			}
+			queue = append(queue, result)
+		case responses <- next:
+			queue = queue[1:]
+		}
+	}
+}
+
+func (c *Chain) initWebsocketClient() error {
+	client, err := tmclient.NewWS(
+		c.TendermintRpcAddress,
+		"/websocket",
+		tmclient.PingPeriod(5*time.Second),
+		tmclient.OnReconnect(func() {
+			log.Info().Str("chain", c.Name).Msg("Reconnected to websocket...")
+
+			// the new txs can be processed before the backfill starts and move the last height
+			// past the missed ones, so the height is taken before subscribing again
+			lastHeight := stateManager.getLastHeight(c.Name)
+			c.subscribeToUpdates()
+
+			// if there's a backfill pending already, it starts from an earlier height
+			select {
+			case c.reconnected <- lastHeight:
+			default:
+			}
+		}),
+	)
+	if err != nil {
+		return err
+	}
+
+	if err = client.Start(); err != nil {
+		return err
+	}
+
+	c.Client = client
+	return nil
+}
+
+func (c *Chain) Stop() {
+	if c.Client != nil {
+		c.Client.Stop() // nolint
+	}
+
+	if c.GrpcWrapper != nil {
+		c.GrpcWrapper.CloseConnection()
+	}
+}
+
+func (c *Chain) subscribeToUpdates() {
+	subscriptions, _ := c.getSubscriptions()
+
+	for _, query := range subscriptions {
+		if err := c.Client.Subscribe(context.Background(), query); err != nil {
+			log.Fatal().Err(err).Str("chain", c.Name).Str("query", query).Msg("Failed to subscribe to query")
+		}
+
+		log.Info().
+			Str("chain", c.Name).
+			Str("query", query).
+			Msg("Listening for incoming transactions")
+	}
+}
+
+func findChainByName(name string) *Chain {
+	for _, chain := range Chains {
+		if chain.Name == name {
+			return chain
+		}
+	}
+
+	return nil
+}
+
+// findChainForCommand is used by chat commands to figure out which chain they refer to.
+// With a single chain configured it's always this chain, otherwise the first word
+// of the command arguments should be a chain name. Returns the arguments without it.
+func findChainForCommand(text string) (*Chain, string, bool) {
+	text = strings.TrimSpace(text)
+
+	if len(Chains) == 1 {
+		return Chains[0], text, true
+	}
+
+	args := strings.SplitN(text, " ", 2)
+	chain := findChainByName(args[0])
+	if chain == nil {
+		return nil, text, false
+	}
+
+	if len(args) < 2 {
+		return chain, "", true
+	}
+
+	return chain, strings.TrimSpace(args[1]), true
+}
+
+// getChainCommandUsage returns the chain argument placeholder for commands usage,
+// which is only needed if there is more than one chain.
+func getChainCommandUsage() string {
+	if len(Chains) > 1 {
+		return "&lt;chain&gt; "
+	}
+
+	return ""
+}
@@ -1,59 +1,81 @@
 package main
 
 import (
+	"fmt"
+	"sync"
 	"time"
 
 	gecko "github.com/superoo7/go-gecko/v3"
 )
 
-type CoingeckoWrapper struct {
+// CoingeckoPriceProvider takes the prices of all the tokens it knows the Coingecko IDs of
+// in one request.
+type CoingeckoPriceProvider struct {
 	client     *gecko.Client
 	currency   string
-	result     float64
+	ids        map[string]string
+	prices     map[string]float64
 	lastUpdate time.Time
+	mutex      sync.Mutex
 }
 
-func NewCoingeckoWrapper(currency string) *CoingeckoWrapper {
-	if currency == "" {
-		log.Info().Msg("Coingecko currency is not set, not intitializing Coingecko wrapper")
-		return &CoingeckoWrapper{}
+func NewCoingeckoPriceProvider(currency string) *CoingeckoPriceProvider {
+	return &CoingeckoPriceProvider{
+		client:   gecko.NewClient(nil),
+		currency: currency,
+		ids:      make(map[string]string),
+		prices:   make(map[string]float64),
 	}
+}
 
-	var cg = gecko.NewClient(nil)
+func (c *CoingeckoPriceProvider) Name() string {
+	return "coingecko"
+}
 
-	return &CoingeckoWrapper{
-		client:   cg,
-		currency: currency,
-	}
+func (c *CoingeckoPriceProvider) addDenom(denom string, id string) {
+	c.ids[denom] = id
 }
 
-func (c *CoingeckoWrapper) GetRate() (float64, error) {
-	if c.client == nil {
-		log.Trace().Msg("Coingecko wrapper not initialized, cannot fetch data.")
-		return 0, nil
+func (c *CoingeckoPriceProvider) GetPrice(denom string) (float64, error) {
+	id, found := c.ids[getPriceKey(denom)]
+	if !found {
+		return 0, fmt.Errorf("no Coingecko ID for denom %s", denom)
 	}
 
-	if !c.lastUpdate.IsZero() && time.Since(c.lastUpdate).Minutes() < 10 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.lastUpdate.IsZero() && time.Since(c.lastUpdate) < PriceCacheDuration {
 		log.Trace().
 			Time("now", time.Now()).
 			Time("then", c.lastUpdate).
 			Dur("diff", time.Since(c.lastUpdate)).
-			Msg("Using rate from cache.")
-		return c.result, nil
+			Msg("Using Coingecko prices from cache.")
+		return c.prices[id], nil
+	}
+
+	ids := make([]string, 0, len(c.ids))
+	for _, id := range c.ids {
+		ids = append(ids, id)
 	}
 
 	log.Debug().
+		Strs("ids", ids).
 		Str("currency", c.currency).
-		Msg("Fetching exchange rate from Coingecko")
+		Msg("Fetching prices from Coingecko")
 
-	result, err := c.client.SimpleSinglePrice(c.currency, "usd")
+	result, err := c.client.SimplePrice(ids, []string{c.currency})
 	if err != nil {
-		log.Warn().Err(err).Msg("Could not get Coingecko exchange rate")
 		return 0, err
 	}
 
-	c.result = float64(result.MarketPrice)
+	prices := make(map[string]float64, len(*result))
+	for id, currencies := range *result {
+		prices[id] = float64(currencies[c.currency])
+	}
+
+	c.prices = prices
 	c.lastUpdate = time.Now()
 
-	return c.result, nil
+	return c.prices[id], nil
 }
@@ -17,16 +17,30 @@ type LabelsConfigManager struct {
 	enabled bool
 }
 
+// Chains may share the same labels config file, so there is one manager per path,
+// otherwise they'd overwrite each other's changes.
+var labelsConfigManagers = make(map[string]*LabelsConfigManager)
+
+func getLabelsConfigManager(path string) *LabelsConfigManager {
+	if manager, found := labelsConfigManagers[path]; found {
+		return manager
+	}
+
+	manager := initLabelsConfig(path)
+	labelsConfigManagers[path] = manager
+	return manager
+}
+
 func initLabelsConfig(path string) *LabelsConfigManager {
-	if LabelsConfigPath == "" {
+	if path == "" {
 		log.Info().Msg("Labels config path not provided, not enabling it.")
 		return &LabelsConfigManager{enabled: false}
 	}
 
-	config := loadConfigFromYaml(LabelsConfigPath)
+	config := loadConfigFromYaml(path)
 	return &LabelsConfigManager{
 		config:  config,
-		path:    LabelsConfigPath,
+		path:    path,
 		enabled: true,
 	}
 }
@@ -62,7 +76,7 @@ func loadConfigFromYaml(path string) LabelsConfig {
 }
 
 func (r *LabelsConfigManager) saveYamlConfig() {
-	f, err := os.Create(LabelsConfigPath)
+	f, err := os.Create(r.path)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Could not open labels config when saving")
 	}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DenomInfo describes how to show a token: its display denom and the coefficient
+// to convert the amount in base denom into it.
+type DenomInfo struct {
+	Base        string
+	Display     string
+	Coefficient sdk.Dec
+	Channel     string
+}
+
+// loadDenoms fills the denoms table with all the bank metadata and figures out the native
+// denom, unless it's set in the config. If something could not be loaded, the amounts
+// are shown in base denom, as it's better than not showing the txs at all.
+func (c *Chain) loadDenoms() {
+	metadatas, err := c.GrpcWrapper.getAllDenomsMetadata()
+	if err != nil {
+		log.Warn().Err(err).Str("chain", c.Name).Msg("Could not load denoms metadata")
+	}
+
+	for _, metadata := range metadatas {
+		display, coefficient, found := getDisplayUnit(metadata)
+		if !found {
+			log.Debug().Str("chain", c.Name).Str("denom", metadata.Base).Msg("Denom has no display unit, skipping")
+			continue
+		}
+
+		c.CacheManager.Cache.DenomsMetadata[metadata.Base] = DenomInfo{
+			Base:        metadata.Base,
+			Display:     display,
+			Coefficient: coefficient,
+		}
+	}
+
+	log.Debug().Str("chain", c.Name).Int("count", len(metadatas)).Msg("Loaded denoms metadata")
+
+	if c.BaseDenom == "" {
+		bondDenom, err := c.GrpcWrapper.getBondDenom()
+		if err != nil {
+			log.Warn().Err(err).Str("chain", c.Name).Msg("Could not get bond denom, set --base-denom to fix it")
+		}
+
+		c.BaseDenom = bondDenom
+	}
+
+	// if --denom and --denom-coefficient are both provided, use them instead of the metadata.
+	// Can be useful for networks like osmosis.
+	if c.Denom != "" && c.DenomCoefficient != 0 {
+		coefficient, err := floatToDec(c.DenomCoefficient)
+		if err != nil || !coefficient.IsPositive() {
+			log.Fatal().
+				Str("chain", c.Name).
+				Float64("coefficient", c.DenomCoefficient).
+				Msg("Invalid denom coefficient")
+		}
+
+		c.denomCoefficient = coefficient
+
+		log.Info().
+			Str("chain", c.Name).
+			Str("denom", c.Denom).
+			Str("coefficient", c.denomCoefficient.String()).
+			Msg("Using provided denom and coefficient.")
+		return
+	}
+
+	info, found := c.CacheManager.Cache.DenomsMetadata[c.BaseDenom]
+	if !found {
+		log.Warn().
+			Str("chain", c.Name).
+			Str("base-denom", c.BaseDenom).
+			Msg("Could not find the native denom metadata, showing amounts in base denom")
+
+		c.Denom = c.BaseDenom
+		c.denomCoefficient = sdk.OneDec()
+		return
+	}
+
+	c.Denom = info.Display
+	c.denomCoefficient = info.Coefficient
+
+	log.Info().
+		Str("chain", c.Name).
+		Str("denom", c.Denom).
+		Str("coefficient", c.denomCoefficient.String()).
+		Msg("Got denom info")
+}
+
+// getDenomInfo resolves the denom through the assetlists and the bank metadata, and for
+// IBC tokens through the denom trace first. If nothing is known about the token,
+// the amounts are shown in base denom.
+func (c *Chain) getDenomInfo(denom string) DenomInfo {
+	if denom == c.BaseDenom {
+		return DenomInfo{
+			Base:        c.BaseDenom,
+			Display:     c.Denom,
+			Coefficient: c.getDenomCoefficient(),
+		}
+	}
+
+	if info, found := c.CacheManager.getDenomInfoFromCache(denom); found {
+		return info
+	}
+
+	info := DenomInfo{
+		Base:        denom,
+		Display:     denom,
+		Coefficient: sdk.OneDec(),
+	}
+
+	// the failed requests are not cached unless there's no such denom,
+	// so the temporary node errors do not break the denom until restart
+	shouldCache := true
+
+	// the assetlists can have IBC tokens both by their hash and by their original base denom
+	tokenDenoms := []string{denom}
+
+	if strings.HasPrefix(denom, "ibc/") {
+		trace, err := c.TokenRegistry.getDenomTrace(denom)
+		if err != nil {
+			log.Warn().Err(err).Str("chain", c.Name).Str("denom", denom).Msg("Could not get denom trace")
+			shouldCache = status.Code(err) == codes.NotFound
+		} else {
+			info.Display = trace.BaseDenom
+			info.Channel = getTraceChannel(trace)
+			tokenDenoms = append(tokenDenoms, trace.BaseDenom)
+		}
+	}
+
+	for _, tokenDenom := range tokenDenoms {
+		if token, found := c.TokenRegistry.getToken(tokenDenom); found {
+			info.Display = token.Symbol
+			info.Coefficient = sdk.NewDecFromInt(sdk.NewIntWithDecimal(1, int(token.Exponent)))
+
+			if shouldCache {
+				c.CacheManager.setDenomInfo(denom, info)
+			}
+
+			return info
+		}
+	}
+
+	// the tokens could have been registered after the start, so they are queried
+	// one by one if they are not in the table
+	if metadata, found := c.CacheManager.Cache.DenomsMetadata[denom]; found {
+		info.Display = metadata.Display
+		info.Coefficient = metadata.Coefficient
+
+		if shouldCache {
+			c.CacheManager.setDenomInfo(denom, info)
+		}
+
+		return info
+	}
+
+	metadata, err := c.GrpcWrapper.getDenomMetadata(denom)
+	if err != nil {
+		if status.Code(err) != codes.NotFound {
+			log.Warn().Err(err).Str("chain", c.Name).Str("denom", denom).Msg("Could not get denom metadata")
+			shouldCache = false
+		}
+	} else if display, coefficient, found := getDisplayUnit(metadata); found {
+		info.Display = display
+		info.Coefficient = coefficient
+	}
+
+	if shouldCache {
+		c.CacheManager.setDenomInfo(denom, info)
+	}
+
+	return info
+}
+
+// getDisplayUnit returns the display denom from the metadata and its coefficient.
+func getDisplayUnit(metadata banktypes.Metadata) (string, sdk.Dec, bool) {
+	for _, unit := range metadata.DenomUnits {
+		if unit.Denom == metadata.Display {
+			return unit.Denom, sdk.NewDecFromInt(sdk.NewIntWithDecimal(1, int(unit.Exponent))), true
+		}
+	}
+
+	return "", sdk.Dec{}, false
+}
+
+// getCoin converts the amount into the display denom of its token.
+func (c *Chain) getCoin(coin sdk.Coin) Coin {
+	amount := sdk.ZeroDec()
+	if !coin.Amount.IsNil() {
+		amount = coin.Amount.ToDec()
+	}
+
+	return c.getDecCoin(sdk.DecCoin{Denom: coin.Denom, Amount: amount})
+}
+
+// getDecCoin is the same as getCoin, but for rewards and commission, which are decimal.
+func (c *Chain) getDecCoin(coin sdk.DecCoin) Coin {
+	info := c.getDenomInfo(coin.Denom)
+
+	return Coin{
+		Amount:    coin.Amount.Quo(info.Coefficient),
+		Denom:     info.Display,
+		BaseDenom: coin.Denom,
+		Channel:   info.Channel,
+	}
+}
+
+func (c *Chain) getCoins(coins sdk.Coins) []Coin {
+	result := make([]Coin, len(coins))
+	for index, coin := range coins {
+		result[index] = c.getCoin(coin)
+	}
+
+	return result
+}
+
+func (c *Chain) getDecCoins(coins sdk.DecCoins) []Coin {
+	result := make([]Coin, len(coins))
+	for index, coin := range coins {
+		result[index] = c.getDecCoin(coin)
+	}
+
+	return result
+}
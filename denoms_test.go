@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+)
+
+func TestGetDisplayUnit(t *testing.T) {
+	tests := []struct {
+		name                string
+		metadata            banktypes.Metadata
+		expectedDenom       string
+		expectedCoefficient string
+		expectedFound       bool
+	}{
+		{
+			name: "atom",
+			metadata: banktypes.Metadata{
+				Base:    "uatom",
+				Display: "atom",
+				DenomUnits: []*banktypes.DenomUnit{
+					{Denom: "uatom", Exponent: 0},
+					{Denom: "matom", Exponent: 3},
+					{Denom: "atom", Exponent: 6},
+				},
+			},
+			expectedDenom:       "atom",
+			expectedCoefficient: "1000000",
+			expectedFound:       true,
+		},
+		{
+			name: "18 decimals",
+			metadata: banktypes.Metadata{
+				Base:    "aevmos",
+				Display: "evmos",
+				DenomUnits: []*banktypes.DenomUnit{
+					{Denom: "aevmos", Exponent: 0},
+					{Denom: "evmos", Exponent: 18},
+				},
+			},
+			expectedDenom:       "evmos",
+			expectedCoefficient: "1000000000000000000",
+			expectedFound:       true,
+		},
+		{
+			name: "display is base",
+			metadata: banktypes.Metadata{
+				Base:       "stake",
+				Display:    "stake",
+				DenomUnits: []*banktypes.DenomUnit{{Denom: "stake", Exponent: 0}},
+			},
+			expectedDenom:       "stake",
+			expectedCoefficient: "1",
+			expectedFound:       true,
+		},
+		{
+			name: "display unit missing",
+			metadata: banktypes.Metadata{
+				Base:       "uatom",
+				Display:    "atom",
+				DenomUnits: []*banktypes.DenomUnit{{Denom: "uatom", Exponent: 0}},
+			},
+			expectedFound: false,
+		},
+		{
+			name:          "no units",
+			metadata:      banktypes.Metadata{Base: "uatom", Display: "atom"},
+			expectedFound: false,
+		},
+	}
+
+	for _, test := range tests {
+		denom, coefficient, found := getDisplayUnit(test.metadata)
+		if found != test.expectedFound {
+			t.Errorf("%s: expected found to be %t, got %t", test.name, test.expectedFound, found)
+			continue
+		}
+
+		if !found {
+			continue
+		}
+
+		if denom != test.expectedDenom {
+			t.Errorf("%s: expected denom %q, got %q", test.name, test.expectedDenom, denom)
+		}
+
+		if !coefficient.Equal(sdk.MustNewDecFromStr(test.expectedCoefficient)) {
+			t.Errorf("%s: expected coefficient %s, got %s", test.name, test.expectedCoefficient, coefficient)
+		}
+	}
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord limits, see https://discord.com/developers/docs/resources/channel#embed-object-embed-limits
+const (
+	DiscordEmbedDescriptionMaxLength = 4096
+	DiscordEmbedFieldValueMaxLength  = 1024
+	DiscordEmbedFieldsMaxCount       = 25
+	DiscordEmbedTotalMaxLength       = 6000
+)
+
+// DiscordEmbedTruncatedFieldLength is the space left for the field saying that not all
+// the messages fit, so it's always possible to add it.
+const DiscordEmbedTruncatedFieldLength = 200
+
+const DiscordFailedTxColor = 0xE74C3C
+
+var discordMsgColors = map[string]int{
+	"MsgSend":                        0x3498DB,
+	"MsgDelegate":                    0x2ECC71,
+	"MsgUndelegate":                  0xE67E22,
+	"MsgBeginRedelegate":             0xF1C40F,
+	"MsgVote":                        0x9B59B6,
+	"MsgSubmitProposal":              0x9B59B6,
+	"MsgWithdrawDelegatorReward":     0x1ABC9C,
+	"MsgWithdrawValidatorCommission": 0x1ABC9C,
+	"MsgSetWithdrawAddress":          0x1ABC9C,
+	"MsgIbcTransfer":                 0x34495E,
+	"MsgIbcRecvPacket":               0x34495E,
+}
+
+const DiscordDefaultColor = 0x95A5A6
+
+// discordSessions are the reporters handling the commands for each bot token. The session is
+// shared by the reporters with the same token, so there's one connection per bot and the commands
+// are registered and answered by the first reporter only.
+var (
+	discordSessions      = make(map[string]*DiscordReporter)
+	discordSessionsMutex sync.Mutex
+)
+
+type DiscordReporter struct {
+	ReporterName    string
+	ReporterQueries []string
+	ReporterFilter  *Filter
+
+	DiscordToken      string
+	DiscordChannel    string
+	DiscordWebhookURL string
+	DiscordGuild      string
+	DiscordAdmins     []string
+
+	DiscordSetAliasCommand    string
+	DiscordClearAliasCommand  string
+	DiscordListAliasesCommand string
+
+	DiscordFailedTxsMode FailedTxsMode
+	DiscordTxFields      TxFields
+
+	DiscordSession     *discordgo.Session
+	MarkdownSerializer Serializer
+
+	webhookID    string
+	webhookToken string
+
+	// the channels and admins of all the reporters with the same bot, the commands are accepted from them
+	commandChannels []string
+	commandAdmins   []string
+}
+
+// Serialize is only used for logging and such, as the reports are sent as embeds.
+func (r DiscordReporter) Serialize(report Report) string {
+	var sb strings.Builder
+
+	serializer := r.Serializer().withChain(report.Chain)
+
+	sb.WriteString(report.Tx.Serialize(serializer) + "\n\n")
+
+	for _, msg := range report.Msgs {
+		sb.WriteString(msg.Serialize(serializer) + "\n\n")
+	}
+
+	return sb.String()
+}
+
+func (r DiscordReporter) SerializeEmbed(report Report) *discordgo.MessageEmbed {
+	serializer := r.Serializer().withChain(report.Chain)
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Tx %s on %s", report.Tx.Hash[0:8], report.Chain.Name),
+		URL:         report.Chain.makeMintscanTxLink(report.Tx.Hash),
+		Description: truncateString(report.Tx.Serialize(serializer), DiscordEmbedDescriptionMaxLength-4),
+		Color:       DiscordDefaultColor,
+		Fields:      []*discordgo.MessageEmbedField{},
+	}
+
+	// Discord rejects the whole embed if its total length is over the limit,
+	// so the messages that do not fit are only counted
+	totalLength := len([]rune(embed.Title)) + len([]rune(embed.Description))
+
+	for index, msg := range report.Msgs {
+		field := &discordgo.MessageEmbedField{
+			Name:  getMsgTypeName(msg),
+			Value: truncateString(msg.Serialize(serializer), DiscordEmbedFieldValueMaxLength-4),
+		}
+
+		fieldLength := len([]rune(field.Name)) + len([]rune(field.Value))
+		isLast := index == len(report.Msgs)-1
+
+		if (!isLast && len(embed.Fields) >= DiscordEmbedFieldsMaxCount-1) ||
+			totalLength+fieldLength > DiscordEmbedTotalMaxLength-DiscordEmbedTruncatedFieldLength {
+			log.Warn().
+				Int("count", len(report.Msgs)).
+				Int("shown", index).
+				Msg("Too many messages in tx for Discord embed, truncating")
+
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:  fmt.Sprintf("And %d more", len(report.Msgs)-index),
+				Value: serializer.LinksSerializer(report.Chain.makeMintscanTxLink(report.Tx.Hash), "See all messages on Mintscan"),
+			})
+			break
+		}
+
+		embed.Fields = append(embed.Fields, field)
+		totalLength += fieldLength
+	}
+
+	if report.Tx.Failed() {
+		embed.Color = DiscordFailedTxColor
+	} else if len(report.Msgs) > 0 {
+		if color, found := discordMsgColors[getMsgTypeName(report.Msgs[0])]; found {
+			embed.Color = color
+		}
+	}
+
+	return embed
+}
+
+func (r *DiscordReporter) Init() {
+	if r.DiscordWebhookURL == "" && (r.DiscordToken == "" || r.DiscordChannel == "") {
+		log.Debug().Msg("Discord credentials not set, not creating Discord reporter.")
+		return
+	}
+
+	r.MarkdownSerializer = NewDiscordMarkdownSerializer(r.DiscordTxFields)
+
+	if r.DiscordWebhookURL != "" {
+		// webhook URLs look like https://discord.com/api/webhooks/<id>/<token>
+		parts := strings.Split(strings.TrimSuffix(r.DiscordWebhookURL, "/"), "/")
+		if len(parts) < 2 {
+			log.Warn().Msg("Could not parse Discord webhook URL")
+			return
+		}
+
+		r.webhookID = parts[len(parts)-2]
+		r.webhookToken = parts[len(parts)-1]
+	}
+
+	if r.DiscordToken != "" {
+		discordSessionsMutex.Lock()
+		defer discordSessionsMutex.Unlock()
+
+		if handler, found := discordSessions[r.DiscordToken]; found {
+			log.Debug().
+				Str("reporter", r.ReporterName).
+				Msg("Discord bot is already used by another reporter, only sending reports to this channel")
+			r.DiscordSession = handler.DiscordSession
+			handler.commandChannels = append(handler.commandChannels, r.DiscordChannel)
+			handler.commandAdmins = append(handler.commandAdmins, r.DiscordAdmins...)
+			return
+		}
+	}
+
+	session, err := discordgo.New("Bot " + r.DiscordToken)
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not create Discord session")
+		return
+	}
+
+	r.DiscordSession = session
+
+	if r.DiscordToken == "" {
+		log.Debug().Msg("Discord bot token not set, not registering slash commands.")
+		return
+	}
+
+	r.commandChannels = []string{r.DiscordChannel}
+	r.commandAdmins = append([]string{}, r.DiscordAdmins...)
+	discordSessions[r.DiscordToken] = r
+
+	r.DiscordSession.AddHandler(r.processInteraction)
+
+	if err := r.DiscordSession.Open(); err != nil {
+		log.Warn().Err(err).Msg("Could not connect to Discord, not registering slash commands")
+		return
+	}
+
+	r.registerCommands()
+}
+
+func (r *DiscordReporter) registerCommands() {
+	chainOptions := []*discordgo.ApplicationCommandOption{}
+	if len(Chains) > 1 {
+		chainOptions = append(chainOptions, &discordgo.ApplicationCommandOption{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "chain",
+			Description: "Chain name",
+			Required:    true,
+		})
+	}
+
+	addressOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "address",
+		Description: "Wallet address",
+		Required:    true,
+	}
+
+	aliasOption := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        "alias",
+		Description: "Wallet alias",
+		Required:    true,
+	}
+
+	commands := []*discordgo.ApplicationCommand{
+		{
+			Name:        r.DiscordSetAliasCommand,
+			Description: "Set wallet alias",
+			Options:     append(append([]*discordgo.ApplicationCommandOption{}, chainOptions...), addressOption, aliasOption),
+		},
+		{
+			Name:        r.DiscordClearAliasCommand,
+			Description: "Clear wallet alias",
+			Options:     append(append([]*discordgo.ApplicationCommandOption{}, chainOptions...), addressOption),
+		},
+		{
+			Name:        r.DiscordListAliasesCommand,
+			Description: "List wallet aliases",
+		},
+	}
+
+	for _, command := range commands {
+		if _, err := r.DiscordSession.ApplicationCommandCreate(
+			r.DiscordSession.State.User.ID,
+			r.DiscordGuild,
+			command,
+		); err != nil {
+			log.Error().Err(err).Str("command", command.Name).Msg("Could not register Discord slash command")
+			continue
+		}
+
+		log.Debug().Str("command", command.Name).Msg("Registered Discord slash command")
+	}
+}
+
+func (r *DiscordReporter) processInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	options := make(map[string]string)
+	for _, option := range data.Options {
+		if option.Type == discordgo.ApplicationCommandOptionString {
+			options[option.Name] = option.StringValue()
+		}
+	}
+
+	user := ""
+	if i.Member != nil && i.Member.User != nil {
+		user = i.Member.User.Username
+	} else if i.User != nil {
+		user = i.User.Username
+	}
+
+	log.Info().
+		Str("command", data.Name).
+		Interface("options", options).
+		Str("user", user).
+		Msg("Received command")
+
+	// the slash commands can be used in any channel the bot is in, so they're only
+	// accepted in the reports channels or from the admins
+	if !r.isAllowed(i) {
+		log.Warn().
+			Str("command", data.Name).
+			Str("channel", i.ChannelID).
+			Str("user", user).
+			Msg("Received command from a channel or user that is not allowed, skipping")
+
+		if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Commands are not allowed here.",
+				Flags:   uint64(discordgo.MessageFlagsEphemeral),
+			},
+		}); err != nil {
+			log.Error().Err(err).Str("command", data.Name).Msg("Could not send response to Discord command")
+		}
+		return
+	}
+
+	var text string
+
+	switch data.Name {
+	case r.DiscordSetAliasCommand:
+		text = r.processSetAliasCommand(options)
+	case r.DiscordClearAliasCommand:
+		text = r.processClearAliasCommand(options)
+	case r.DiscordListAliasesCommand:
+		text = r.processListAliasesCommand()
+	default:
+		log.Debug().Msg("Unsupported command, skipping.")
+		return
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: truncateString(text, 2000-4),
+		},
+	}); err != nil {
+		log.Error().Err(err).Str("command", data.Name).Msg("Could not send response to Discord command")
+	}
+}
+
+func (r *DiscordReporter) isAllowed(i *discordgo.InteractionCreate) bool {
+	for _, channel := range r.commandChannels {
+		if i.ChannelID == channel {
+			return true
+		}
+	}
+
+	userID := ""
+	if i.Member != nil && i.Member.User != nil {
+		userID = i.Member.User.ID
+	} else if i.User != nil {
+		userID = i.User.ID
+	}
+
+	return userID != "" && contains(r.commandAdmins, userID)
+}
+
+func (r *DiscordReporter) processSetAliasCommand(options map[string]string) string {
+	chain, _, found := findChainForCommand(options["chain"])
+	if !found {
+		return fmt.Sprintf("Chain %s is not found", r.MarkdownSerializer.CodeSerializer(options["chain"]))
+	}
+
+	chain.LabelsConfigManager.setWalletLabel(options["address"], options["alias"])
+	return fmt.Sprintf(
+		"Successfully set alias for %s: %s",
+		r.MarkdownSerializer.LinksSerializer(chain.makeMintscanAccountLink(options["address"]), options["address"]),
+		r.MarkdownSerializer.CodeSerializer(options["alias"]),
+	)
+}
+
+func (r *DiscordReporter) processClearAliasCommand(options map[string]string) string {
+	chain, _, found := findChainForCommand(options["chain"])
+	if !found {
+		return fmt.Sprintf("Chain %s is not found", r.MarkdownSerializer.CodeSerializer(options["chain"]))
+	}
+
+	chain.LabelsConfigManager.clearWalletLabel(options["address"])
+	return fmt.Sprintf(
+		"Successfully cleared alias for %s",
+		r.MarkdownSerializer.LinksSerializer(chain.makeMintscanAccountLink(options["address"]), options["address"]),
+	)
+}
+
+func (r *DiscordReporter) processListAliasesCommand() string {
+	var sb strings.Builder
+
+	for _, chain := range Chains {
+		sb.WriteString(r.MarkdownSerializer.StrongSerializer(
+			fmt.Sprintf("Wallet aliases on %s:", chain.Name),
+		) + "\n")
+
+		if len(chain.LabelsConfigManager.config.WalletLabels) == 0 {
+			sb.WriteString(fmt.Sprintf(
+				"No label aliases are set. You can set one using `/%s`\n",
+				r.DiscordSetAliasCommand,
+			))
+		}
+
+		for key, value := range chain.LabelsConfigManager.config.WalletLabels {
+			sb.WriteString(fmt.Sprintf(
+				"• %s: %s\n",
+				r.MarkdownSerializer.LinksSerializer(chain.makeMintscanAccountLink(key), key),
+				r.MarkdownSerializer.CodeSerializer(value),
+			))
+		}
+	}
+
+	return sb.String()
+}
+
+func (r DiscordReporter) Enabled() bool {
+	return r.DiscordSession != nil
+}
+
+func (r DiscordReporter) Serializer() Serializer {
+	return r.MarkdownSerializer
+}
+
+func (r DiscordReporter) SendReport(report Report) error {
+	embed := r.SerializeEmbed(report)
+
+	if r.webhookID != "" {
+		_, err := r.DiscordSession.WebhookExecute(r.webhookID, r.webhookToken, false, &discordgo.WebhookParams{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		})
+		return err
+	}
+
+	_, err := r.DiscordSession.ChannelMessageSendEmbed(r.DiscordChannel, embed)
+	return err
+}
+
+func (r DiscordReporter) Name() string {
+	return r.ReporterName
+}
+
+func (r DiscordReporter) FailedTxsMode() FailedTxsMode {
+	return r.DiscordFailedTxsMode
+}
+
+func (r DiscordReporter) Queries() []string {
+	return r.ReporterQueries
+}
+
+func (r DiscordReporter) Filter() *Filter {
+	return r.ReporterFilter
+}
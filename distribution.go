@@ -9,6 +9,12 @@ import (
 	"github.com/gogo/protobuf/proto"
 )
 
+func init() {
+	RegisterMsgParser("/cosmos.distribution.v1beta1.MsgSetWithdrawAddress", ParseMsgSetWithdrawAddress)
+	RegisterMsgParser("/cosmos.distribution.v1beta1.MsgWithdrawDelegatorReward", ParseMsgWithdrawDelegatorReward)
+	RegisterMsgParser("/cosmos.distribution.v1beta1.MsgWithdrawValidatorCommission", ParseMsgWithdrawValidatorCommission)
+}
+
 type MsgWithdrawDelegatorReward struct {
 	ValidatorAddress string
 	DelegatorAddress string
@@ -38,7 +44,40 @@ func (msg MsgWithdrawDelegatorReward) Serialize(serializer Serializer) string {
 	return sb.String()
 }
 
-func ParseMsgWithdrawDelegatorReward(message *cosmosTypes.Any, block int64) MsgWithdrawDelegatorReward {
+func (msg MsgWithdrawDelegatorReward) Summary(chain *Chain) string {
+	return fmt.Sprintf(
+		"Withdraw rewards of %s from %s",
+		chain.getWalletSummary(msg.DelegatorAddress),
+		chain.getValidatorSummary(msg.ValidatorAddress),
+	)
+}
+
+type JSONMsgWithdrawDelegatorReward struct {
+	Delegator JSONAddress   `json:"delegator"`
+	Validator JSONValidator `json:"validator"`
+	Rewards   []JSONAmount  `json:"rewards"`
+}
+
+func (msg MsgWithdrawDelegatorReward) SerializeJSON(chain *Chain) interface{} {
+	rewards := []JSONAmount{}
+
+	if response, err := chain.GrpcWrapper.getDelegatorRewardsAtBlock(msg.ValidatorAddress, msg.DelegatorAddress, msg.Block-1); err != nil {
+		log.Warn().Err(err).
+			Str("validator", msg.ValidatorAddress).
+			Str("delegator", msg.DelegatorAddress).
+			Msg("Could not load delegator rewards info")
+	} else {
+		rewards = chain.getJSONDecCoins(response)
+	}
+
+	return JSONMsgWithdrawDelegatorReward{
+		Delegator: chain.getJSONAddress(msg.DelegatorAddress),
+		Validator: chain.getJSONValidator(msg.ValidatorAddress),
+		Rewards:   rewards,
+	}
+}
+
+func ParseMsgWithdrawDelegatorReward(message *cosmosTypes.Any, chain *Chain, block int64) Msg {
 	var parsedMessage cosmosDistributionTypes.MsgWithdrawDelegatorReward
 	if err := proto.Unmarshal(message.Value, &parsedMessage); err != nil {
 		log.Error().Err(err).Msg("Could not parse MsgWithdrawDelegatorReward")
@@ -66,7 +105,7 @@ func (msg MsgSetWithdrawAddress) Empty() bool {
 	return msg.DelegatorAddress == ""
 }
 
-func ParseMsgSetWithdrawAddress(message *cosmosTypes.Any) MsgSetWithdrawAddress {
+func ParseMsgSetWithdrawAddress(message *cosmosTypes.Any, chain *Chain, block int64) Msg {
 	var parsedMessage cosmosDistributionTypes.MsgSetWithdrawAddress
 	if err := proto.Unmarshal(message.Value, &parsedMessage); err != nil {
 		log.Error().Err(err).Msg("Could not parse MsgSetWithdrawAddress")
@@ -90,12 +129,32 @@ func (msg MsgSetWithdrawAddress) Serialize(serializer Serializer) string {
 %s %s`,
 		serializer.StrongSerializer("Set withdraw address"),
 		serializer.StrongSerializer("By:"),
-		serializer.LinksSerializer(makeMintscanAccountLink(msg.DelegatorAddress), msg.DelegatorAddress),
+		serializer.LinksSerializer(serializer.Chain.makeMintscanAccountLink(msg.DelegatorAddress), msg.DelegatorAddress),
 		serializer.StrongSerializer("New withdraw address: "),
 		serializer.getWalletWithLabel(msg.WithdrawAddress),
 	)
 }
 
+func (msg MsgSetWithdrawAddress) Summary(chain *Chain) string {
+	return fmt.Sprintf(
+		"Set withdraw address of %s to %s",
+		chain.getWalletSummary(msg.DelegatorAddress),
+		chain.getWalletSummary(msg.WithdrawAddress),
+	)
+}
+
+type JSONMsgSetWithdrawAddress struct {
+	Delegator       JSONAddress `json:"delegator"`
+	WithdrawAddress JSONAddress `json:"withdraw_address"`
+}
+
+func (msg MsgSetWithdrawAddress) SerializeJSON(chain *Chain) interface{} {
+	return JSONMsgSetWithdrawAddress{
+		Delegator:       chain.getJSONAddress(msg.DelegatorAddress),
+		WithdrawAddress: chain.getJSONAddress(msg.WithdrawAddress),
+	}
+}
+
 type MsgWithdrawValidatorCommission struct {
 	ValidatorAddress string
 	Block            int64
@@ -105,7 +164,7 @@ func (msg MsgWithdrawValidatorCommission) Empty() bool {
 	return msg.ValidatorAddress == ""
 }
 
-func ParseMsgWithdrawValidatorCommission(message *cosmosTypes.Any, block int64) MsgWithdrawValidatorCommission {
+func ParseMsgWithdrawValidatorCommission(message *cosmosTypes.Any, chain *Chain, block int64) Msg {
 	var parsedMessage cosmosDistributionTypes.MsgWithdrawValidatorCommission
 	if err := proto.Unmarshal(message.Value, &parsedMessage); err != nil {
 		log.Error().Err(err).Msg("Could not parse MsgWithdrawValidatorCommission")
@@ -135,3 +194,27 @@ func (msg MsgWithdrawValidatorCommission) Serialize(serializer Serializer) strin
 
 	return sb.String()
 }
+
+func (msg MsgWithdrawValidatorCommission) Summary(chain *Chain) string {
+	return fmt.Sprintf("Withdraw commission of %s", chain.getValidatorSummary(msg.ValidatorAddress))
+}
+
+type JSONMsgWithdrawValidatorCommission struct {
+	Validator  JSONValidator `json:"validator"`
+	Commission []JSONAmount  `json:"commission"`
+}
+
+func (msg MsgWithdrawValidatorCommission) SerializeJSON(chain *Chain) interface{} {
+	commission := []JSONAmount{}
+
+	if response, err := chain.GrpcWrapper.getValidatorCommissionAtBlock(msg.ValidatorAddress, msg.Block-1); err != nil {
+		log.Warn().Err(err).Str("address", msg.ValidatorAddress).Msg("Could not load validator commission info")
+	} else {
+		commission = chain.getJSONDecCoins(response)
+	}
+
+	return JSONMsgWithdrawValidatorCommission{
+		Validator:  chain.getJSONValidator(msg.ValidatorAddress),
+		Commission: commission,
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+type SmtpTLSMode string
+
+const (
+	SmtpTLSModeNone     SmtpTLSMode = "none"
+	SmtpTLSModeStartTLS SmtpTLSMode = "starttls"
+	SmtpTLSModeTLS      SmtpTLSMode = "tls"
+)
+
+func parseSmtpTLSMode(value string) SmtpTLSMode {
+	mode := SmtpTLSMode(value)
+
+	switch mode {
+	case SmtpTLSModeNone, SmtpTLSModeStartTLS, SmtpTLSModeTLS:
+		return mode
+	default:
+		log.Fatal().Str("value", value).Msg("Invalid email TLS mode, expected none, starttls or tls")
+		return ""
+	}
+}
+
+type EmailReporter struct {
+	ReporterName    string
+	ReporterQueries []string
+	ReporterFilter  *Filter
+
+	EmailHost     string
+	EmailPort     int
+	EmailUsername string
+	EmailPassword string
+	EmailTLSMode  SmtpTLSMode
+	EmailFrom     string
+	EmailTo       []string
+
+	EmailFailedTxsMode FailedTxsMode
+	EmailTxFields      TxFields
+
+	HtmlSerializer  Serializer
+	PlainSerializer Serializer
+}
+
+func (r EmailReporter) serializeWith(report Report, serializer Serializer) string {
+	var sb strings.Builder
+
+	serializer = serializer.withChain(report.Chain)
+
+	sb.WriteString(report.Tx.Serialize(serializer) + "\n\n")
+
+	for _, msg := range report.Msgs {
+		sb.WriteString(msg.Serialize(serializer) + "\n\n")
+	}
+
+	return sb.String()
+}
+
+func (r EmailReporter) Serialize(report Report) string {
+	return r.serializeWith(report, r.PlainSerializer)
+}
+
+func (r *EmailReporter) Init() {
+	if r.EmailHost == "" || r.EmailFrom == "" || len(r.EmailTo) == 0 {
+		log.Debug().Msg("Email settings not set, not creating email reporter.")
+		return
+	}
+
+	r.HtmlSerializer = NewHtmlSerializer(r.EmailTxFields)
+	r.PlainSerializer = NewPlainSerializer(r.EmailTxFields)
+}
+
+func (r EmailReporter) Enabled() bool {
+	return r.HtmlSerializer.LinksSerializer != nil
+}
+
+func (r EmailReporter) Serializer() Serializer {
+	return r.HtmlSerializer
+}
+
+// buildMessage renders the report into a multipart/alternative message,
+// with a plain text part and an HTML one.
+func (r EmailReporter) buildMessage(report Report) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		ContentType string
+		Text        string
+	}{
+		{"text/plain", r.serializeWith(report, r.PlainSerializer)},
+		{"text/html", "<html><body>" + getHtmlWithLineBreaks(r.serializeWith(report, r.HtmlSerializer)) + "</body></html>"},
+	}
+
+	for _, part := range parts {
+		partWriter, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.ContentType + "; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		quotedWriter := quotedprintable.NewWriter(partWriter)
+		if _, err := quotedWriter.Write([]byte(part.Text)); err != nil {
+			return nil, err
+		}
+
+		if err := quotedWriter.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+
+	headers := []string{
+		"From: " + r.EmailFrom,
+		"To: " + strings.Join(r.EmailTo, ", "),
+		"Subject: " + mime.QEncoding.Encode("UTF-8", report.Summary()),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + writer.Boundary(),
+	}
+
+	message.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+	message.Write(body.Bytes())
+
+	return message.Bytes(), nil
+}
+
+func (r EmailReporter) getClient() (*smtp.Client, error) {
+	address := net.JoinHostPort(r.EmailHost, fmt.Sprintf("%d", r.EmailPort))
+	tlsConfig := &tls.Config{ServerName: r.EmailHost}
+
+	if r.EmailTLSMode == SmtpTLSModeTLS {
+		conn, err := tls.Dial("tcp", address, tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		return smtp.NewClient(conn, r.EmailHost)
+	}
+
+	client, err := smtp.Dial(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.EmailTLSMode == SmtpTLSModeStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+func (r EmailReporter) SendReport(report Report) error {
+	message, err := r.buildMessage(report)
+	if err != nil {
+		return err
+	}
+
+	client, err := r.getClient()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if r.EmailUsername != "" {
+		if err := client.Auth(smtp.PlainAuth("", r.EmailUsername, r.EmailPassword, r.EmailHost)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(r.EmailFrom); err != nil {
+		return err
+	}
+
+	for _, recipient := range r.EmailTo {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (r EmailReporter) Name() string {
+	return r.ReporterName
+}
+
+func (r EmailReporter) FailedTxsMode() FailedTxsMode {
+	return r.EmailFailedTxsMode
+}
+
+func (r EmailReporter) Queries() []string {
+	return r.ReporterQueries
+}
+
+func (r EmailReporter) Filter() *Filter {
+	return r.ReporterFilter
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filter is an expression evaluated on the parsed messages, like
+// `type == Delegate && validator == SOLAR && usd > 10000`. A report matches the filter
+// if at least one of its messages matches it.
+//
+// The grammar is the following:
+//
+//	expression := and ("||" and)*
+//	and        := not ("&&" not)*
+//	not        := "!" not | "(" expression ")" | field operator value
+//	operator   := "==" | "!=" | ">" | ">=" | "<" | "<=" | "=~"
+//
+// Values are either numbers, quoted strings or bare words. The fields with several values
+// (like address) are equal to a value if any of them is, and not equal if none of them is.
+type Filter struct {
+	Expression string
+	root       filterNode
+}
+
+type filterNode interface {
+	matches(context filterContext) bool
+}
+
+type filterContext struct {
+	report Report
+	msg    Msg
+}
+
+var filterStringFields = map[string]func(filterContext) []string{
+	"type": func(context filterContext) []string {
+		typeName := getMsgTypeName(context.msg)
+		return []string{typeName, strings.TrimPrefix(typeName, "Msg")}
+	},
+	"chain": func(context filterContext) []string {
+		return []string{context.report.Chain.Name}
+	},
+	"validator": func(context filterContext) []string {
+		values := []string{}
+		for _, address := range getMsgValidators(context.msg) {
+			values = append(values, address, context.report.Chain.getValidatorSummary(address))
+		}
+		return values
+	},
+	"address": func(context filterContext) []string {
+		return getMsgAddresses(context.msg)
+	},
+	"label": func(context filterContext) []string {
+		labels := []string{}
+		for _, address := range getMsgAddresses(context.msg) {
+			if label, found := context.report.Chain.LabelsConfigManager.getWalletLabel(address); found {
+				labels = append(labels, label)
+			}
+		}
+		return labels
+	},
+	"signer": func(context filterContext) []string {
+		return context.report.Tx.Signers
+	},
+	"memo": func(context filterContext) []string {
+		return []string{context.report.Tx.Memo}
+	},
+	"query": func(context filterContext) []string {
+		return context.report.Tx.MatchedQueries
+	},
+	"failed": func(context filterContext) []string {
+		return []string{strconv.FormatBool(context.report.Tx.Failed())}
+	},
+}
+
+// getMsgFiatValue returns the native tokens amount of the message in fiat currency.
+func getMsgFiatValue(context filterContext) (float64, bool) {
+	amount, found := getMsgNativeAmount(context.msg, context.report.Chain)
+	if !found {
+		return 0, false
+	}
+
+	price, found := context.report.Chain.PriceManager.getPrice(context.report.Chain.BaseDenom)
+	if !found {
+		return 0, false
+	}
+
+	amountDec, err := floatToDec(amount)
+	if err != nil {
+		return 0, false
+	}
+
+	return getFiatValue(amountDec, price).MustFloat64(), true
+}
+
+var filterNumberFields = map[string]func(filterContext) (float64, bool){
+	"amount": func(context filterContext) (float64, bool) {
+		return getMsgNativeAmount(context.msg, context.report.Chain)
+	},
+	"fiat": getMsgFiatValue,
+	// the old name, from the times the prices were only in USD
+	"usd": getMsgFiatValue,
+	"height": func(context filterContext) (float64, bool) {
+		return float64(context.report.Tx.Height), true
+	},
+}
+
+func ParseFilter(expression string) (*Filter, error) {
+	parser := &filterParser{}
+	if err := parser.tokenize(expression); err != nil {
+		return nil, err
+	}
+
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if parser.position < len(parser.tokens) {
+		return nil, fmt.Errorf("unexpected %s", parser.tokens[parser.position])
+	}
+
+	return &Filter{Expression: expression, root: root}, nil
+}
+
+// mustParseFilter parses the filter set for a chain or reporter, exiting if it's invalid.
+func mustParseFilter(expression string, kind string, name string) *Filter {
+	if expression == "" {
+		return nil
+	}
+
+	filter, err := ParseFilter(expression)
+	if err != nil {
+		log.Fatal().Err(err).Str(kind, name).Str("filter", expression).Msg("Could not parse filter")
+	}
+
+	return filter
+}
+
+// Matches returns whether any of the report messages matches the filter.
+// Empty filter matches everything.
+func (f *Filter) Matches(report Report) bool {
+	if f == nil {
+		return true
+	}
+
+	for _, msg := range report.Msgs {
+		if f.root.matches(filterContext{report: report, msg: msg}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type filterAnd struct{ left, right filterNode }
+type filterOr struct{ left, right filterNode }
+type filterNot struct{ node filterNode }
+
+func (node filterAnd) matches(context filterContext) bool {
+	return node.left.matches(context) && node.right.matches(context)
+}
+
+func (node filterOr) matches(context filterContext) bool {
+	return node.left.matches(context) || node.right.matches(context)
+}
+
+func (node filterNot) matches(context filterContext) bool {
+	return !node.node.matches(context)
+}
+
+type filterComparison struct {
+	field    string
+	operator string
+	value    string
+	number   float64
+	regexp   *regexp.Regexp
+}
+
+func (node filterComparison) matches(context filterContext) bool {
+	if getNumber, found := filterNumberFields[node.field]; found {
+		value, found := getNumber(context)
+		if !found {
+			return false
+		}
+
+		switch node.operator {
+		case "==":
+			return value == node.number
+		case "!=":
+			return value != node.number
+		case ">":
+			return value > node.number
+		case ">=":
+			return value >= node.number
+		case "<":
+			return value < node.number
+		default:
+			return value <= node.number
+		}
+	}
+
+	values := filterStringFields[node.field](context)
+
+	switch node.operator {
+	case "==":
+		return contains(values, node.value)
+	case "!=":
+		return !contains(values, node.value)
+	default:
+		for _, value := range values {
+			if node.regexp.MatchString(value) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+type filterParser struct {
+	tokens   []string
+	position int
+}
+
+// filterOperatorChars are the characters the operators are made of.
+const filterOperatorChars = "=!<>&|~"
+
+func (p *filterParser) tokenize(expression string) error {
+	runes := []rune(expression)
+
+	for index := 0; index < len(runes); {
+		char := runes[index]
+
+		switch {
+		case unicode.IsSpace(char):
+			index++
+		case char == '(' || char == ')':
+			p.tokens = append(p.tokens, string(char))
+			index++
+		case char == '"' || char == '\'':
+			end := index + 1
+			for end < len(runes) && runes[end] != char {
+				end++
+			}
+
+			if end >= len(runes) {
+				return fmt.Errorf("unterminated string at position %d", index)
+			}
+
+			// keeping the opening quote, so the parser knows it's a value
+			p.tokens = append(p.tokens, string(runes[index:end]))
+			index = end + 1
+		case char == '!' && (index+1 >= len(runes) || runes[index+1] != '='):
+			// a negation, which can be followed by another one, like !!
+			p.tokens = append(p.tokens, "!")
+			index++
+		case strings.ContainsRune(filterOperatorChars, char):
+			end := index + 1
+			for end < len(runes) && strings.ContainsRune(filterOperatorChars, runes[end]) {
+				end++
+			}
+
+			p.tokens = append(p.tokens, string(runes[index:end]))
+			index = end
+		default:
+			end := index
+			for end < len(runes) &&
+				!unicode.IsSpace(runes[end]) &&
+				!strings.ContainsRune("()=!<>&|~\"'", runes[end]) {
+				end++
+			}
+
+			p.tokens = append(p.tokens, string(runes[index:end]))
+			index = end
+		}
+	}
+
+	return nil
+}
+
+// isFilterOperator checks if the token is an operator, like && or ==. The quoted values
+// always start with a quote, so they're never taken for operators.
+func isFilterOperator(token string) bool {
+	return token != "" && strings.ContainsRune(filterOperatorChars, []rune(token)[0])
+}
+
+func (p *filterParser) peek() string {
+	if p.position >= len(p.tokens) {
+		return ""
+	}
+
+	return p.tokens[p.position]
+}
+
+func (p *filterParser) next() (string, error) {
+	if p.position >= len(p.tokens) {
+		return "", fmt.Errorf("unexpected end of filter")
+	}
+
+	token := p.tokens[p.position]
+	p.position++
+	return token, nil
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "||" {
+		p.position++
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = filterOr{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "&&" {
+		p.position++
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		left = filterAnd{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (filterNode, error) {
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case "!":
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return filterNot{node: node}, nil
+	case "(":
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing, err := p.next(); err != nil || closing != ")" {
+			return nil, fmt.Errorf("expected )")
+		}
+
+		return node, nil
+	default:
+		return p.parseComparison(token)
+	}
+}
+
+func (p *filterParser) parseComparison(field string) (filterNode, error) {
+	_, isString := filterStringFields[field]
+	_, isNumber := filterNumberFields[field]
+	if !isString && !isNumber {
+		return nil, fmt.Errorf("unknown field %s", field)
+	}
+
+	operator, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if value == "(" || value == ")" || isFilterOperator(value) {
+		return nil, fmt.Errorf("expected a value after %s %s", field, operator)
+	}
+
+	// only the opening quote is kept by the tokenizer, the value itself can start with a quote
+	if strings.HasPrefix(value, "\"") || strings.HasPrefix(value, "'") {
+		value = value[1:]
+	}
+	node := filterComparison{field: field, operator: operator, value: value}
+
+	if isNumber {
+		switch operator {
+		case "==", "!=", ">", ">=", "<", "<=":
+		default:
+			return nil, fmt.Errorf("unsupported operator %s for %s", operator, field)
+		}
+
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number for %s, got %s", field, value)
+		}
+
+		node.number = number
+		return node, nil
+	}
+
+	switch operator {
+	case "==", "!=":
+	case "=~":
+		compiled, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp %s: %s", value, err)
+		}
+
+		node.regexp = compiled
+	default:
+		return nil, fmt.Errorf("unsupported operator %s for %s", operator, field)
+	}
+
+	return node, nil
+}
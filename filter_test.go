@@ -0,0 +1,188 @@
+package main
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func getFilterTestReport(msgs ...Msg) Report {
+	return Report{
+		Chain: &Chain{ChainConfig: ChainConfig{Name: "cosmos", BaseDenom: "uatom"}},
+		Tx: Tx{
+			Hash:           "ABCDEF0123456789",
+			Height:         100,
+			Memo:           "Claim your airdrop at <a href='x'>",
+			Signers:        []string{"cosmos1sender"},
+			MatchedQueries: []string{"transfers"},
+		},
+		Msgs: msgs,
+	}
+}
+
+func getFilterTestSend(amount string) MsgSend {
+	return MsgSend{
+		FromAddress: "cosmos1sender",
+		ToAddress:   "cosmos1receiver",
+		Coins: []Coin{
+			{Amount: sdk.MustNewDecFromStr(amount), Denom: "atom", BaseDenom: "uatom"},
+		},
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	report := getFilterTestReport(getFilterTestSend("150"))
+
+	tests := []struct {
+		expression string
+		expected   bool
+	}{
+		{"type == Send", true},
+		{"type == MsgSend", true},
+		{"type != Send", false},
+		{"chain == cosmos", true},
+		{"address == cosmos1receiver", true},
+		{"address != cosmos1receiver", false},
+		{"signer == cosmos1sender", true},
+		{"query == transfers", true},
+		{"failed == false", true},
+
+		// numeric operators
+		{"amount == 150", true},
+		{"amount != 150", false},
+		{"amount > 100", true},
+		{"amount > 150", false},
+		{"amount >= 150", true},
+		{"amount < 150", false},
+		{"amount <= 150", true},
+		{"height > 99 && height < 101", true},
+
+		// quoted values
+		{"memo == 'Claim your airdrop at <a href=\"x\">'", false},
+		{`memo == "Claim your airdrop at <a href='x'>"`, true},
+		{"chain == 'cosmos'", true},
+		{`chain == "'cosmos"`, false},
+		{`memo == "'Claim your airdrop at <a href='x'>"`, false},
+
+		// regexps
+		{"memo =~ 'airdrop|claim'", true},
+		{"memo =~ '^airdrop'", false},
+		{"address =~ receiver$", true},
+
+		// && binds tighter than ||
+		{"type == Delegate && amount > 1000 || chain == cosmos", true},
+		{"chain == cosmos || type == Delegate && amount > 1000", true},
+		{"(chain == cosmos || type == Delegate) && amount > 1000", false},
+		{"type == Delegate && (amount > 1000 || chain == cosmos)", false},
+
+		// negation
+		{"!type == Delegate", true},
+		{"!(type == Send && amount > 100)", false},
+		{"!!type == Send", true},
+		{"!(type == Delegate) && !(amount < 100)", true},
+	}
+
+	for _, test := range tests {
+		filter, err := ParseFilter(test.expression)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", test.expression, err)
+			continue
+		}
+
+		if matches := filter.Matches(report); matches != test.expected {
+			t.Errorf("%s: expected %t, got %t", test.expression, test.expected, matches)
+		}
+	}
+}
+
+func TestFilterMatchesAnyMessage(t *testing.T) {
+	report := getFilterTestReport(
+		MsgDelegate{
+			DelegatorAddress: "cosmos1sender",
+			Token:            Coin{Amount: sdk.MustNewDecFromStr("5"), Denom: "atom", BaseDenom: "uatom"},
+		},
+		getFilterTestSend("150"),
+	)
+
+	filter, err := ParseFilter("type == Send && amount > 100")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !filter.Matches(report) {
+		t.Errorf("expected the second message to match")
+	}
+
+	// the fields are checked on the same message
+	filter, err = ParseFilter("type == Delegate && amount > 100")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if filter.Matches(report) {
+		t.Errorf("expected no message to match")
+	}
+}
+
+func TestFilterIbcAmount(t *testing.T) {
+	native := Coin{Amount: sdk.MustNewDecFromStr("20"), Denom: "atom", BaseDenom: "uatom"}
+	foreign := Coin{Amount: sdk.MustNewDecFromStr("20"), Denom: "OSMO", BaseDenom: "ibc/ABC"}
+
+	tests := []struct {
+		msg      Msg
+		expected bool
+	}{
+		{MsgIbcTransfer{FromAddress: "cosmos1sender", Token: native}, true},
+		{MsgIbcTransfer{FromAddress: "cosmos1sender", Token: foreign}, false},
+		{MsgIbcRecvPacket{Signer: "cosmos1relayer", Token: &native}, true},
+		{MsgIbcRecvPacket{Signer: "cosmos1relayer", Token: &foreign}, false},
+		{MsgIbcRecvPacket{Signer: "cosmos1relayer"}, false},
+	}
+
+	filter, err := ParseFilter("amount > 10")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for index, test := range tests {
+		if matches := filter.Matches(getFilterTestReport(test.msg)); matches != test.expected {
+			t.Errorf("%d: expected %t, got %t", index, test.expected, matches)
+		}
+	}
+}
+
+func TestNilFilterMatchesEverything(t *testing.T) {
+	var filter *Filter
+
+	if !filter.Matches(getFilterTestReport(getFilterTestSend("1"))) {
+		t.Errorf("expected nil filter to match")
+	}
+}
+
+func TestFilterParseErrors(t *testing.T) {
+	expressions := []string{
+		"",
+		"type",
+		"type ==",
+		"unknown == 1",
+		"amount > abc",
+		"amount =~ 1",
+		"type > Send",
+		"type == Send &&",
+		"(type == Send",
+		"type == Send)",
+		"type == 'Send",
+		"memo =~ '('",
+		"type == (",
+		"type == && chain == cosmos",
+		"type == || chain == cosmos",
+		"amount > !",
+		"type == Send chain == cosmos",
+	}
+
+	for _, expression := range expressions {
+		if _, err := ParseFilter(expression); err == nil {
+			t.Errorf("%q: expected an error", expression)
+		}
+	}
+}
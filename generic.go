@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+	cosmosTypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/std"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	authTypes "github.com/cosmos/cosmos-sdk/x/auth/types"
+	vestingTypes "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
+	authzTypes "github.com/cosmos/cosmos-sdk/x/authz"
+	bankTypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	crisisTypes "github.com/cosmos/cosmos-sdk/x/crisis/types"
+	distributionTypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	evidenceTypes "github.com/cosmos/cosmos-sdk/x/evidence/types"
+	feegrantTypes "github.com/cosmos/cosmos-sdk/x/feegrant"
+	govTypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+	paramsProposalTypes "github.com/cosmos/cosmos-sdk/x/params/types/proposal"
+	slashingTypes "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	stakingTypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	upgradeTypes "github.com/cosmos/cosmos-sdk/x/upgrade/types"
+	ibcTransferTypes "github.com/cosmos/ibc-go/modules/apps/transfer/types"
+	ibcTypes "github.com/cosmos/ibc-go/modules/core/types"
+)
+
+// Messages with longer JSON bodies are truncated, so a single huge message
+// (like a proposal with a long description) does not flood the chat.
+const MaxGenericMsgBodyLength = 1000
+
+var (
+	interfaceRegistry = makeInterfaceRegistry()
+	protoCodec        = codec.NewProtoCodec(interfaceRegistry)
+)
+
+func makeInterfaceRegistry() cosmosTypes.InterfaceRegistry {
+	registry := cosmosTypes.NewInterfaceRegistry()
+
+	std.RegisterInterfaces(registry)
+	authTypes.RegisterInterfaces(registry)
+	vestingTypes.RegisterInterfaces(registry)
+	authzTypes.RegisterInterfaces(registry)
+	bankTypes.RegisterInterfaces(registry)
+	crisisTypes.RegisterInterfaces(registry)
+	distributionTypes.RegisterInterfaces(registry)
+	evidenceTypes.RegisterInterfaces(registry)
+	feegrantTypes.RegisterInterfaces(registry)
+	govTypes.RegisterInterfaces(registry)
+	paramsProposalTypes.RegisterInterfaces(registry)
+	slashingTypes.RegisterInterfaces(registry)
+	stakingTypes.RegisterInterfaces(registry)
+	upgradeTypes.RegisterInterfaces(registry)
+	ibcTransferTypes.RegisterInterfaces(registry)
+	ibcTypes.RegisterInterfaces(registry)
+
+	return registry
+}
+
+// MsgGeneric is used for messages that do not have a dedicated parser,
+// it's displayed as the message type and its JSON representation.
+type MsgGeneric struct {
+	TypeUrl string
+	Body    string
+}
+
+func (msg MsgGeneric) Empty() bool {
+	return msg.TypeUrl == ""
+}
+
+func (msg MsgGeneric) Serialize(serializer Serializer) string {
+	var sb strings.Builder
+
+	sb.WriteString(serializer.StrongSerializer(msg.getTypeName()) + "\n")
+	sb.WriteString(serializer.MultilineCodeSerializer(truncateString(msg.Body, MaxGenericMsgBodyLength)))
+
+	return sb.String()
+}
+
+func (msg MsgGeneric) Summary(chain *Chain) string {
+	return msg.getTypeName()
+}
+
+type JSONMsgGeneric struct {
+	TypeUrl string      `json:"type_url"`
+	Value   interface{} `json:"value"`
+}
+
+func (msg MsgGeneric) SerializeJSON(chain *Chain) interface{} {
+	result := JSONMsgGeneric{
+		TypeUrl: msg.TypeUrl,
+		Value:   msg.Body,
+	}
+
+	// the body is not a JSON if the message could not be decoded
+	if json.Valid([]byte(msg.Body)) {
+		result.Value = json.RawMessage(msg.Body)
+	}
+
+	return result
+}
+
+// getTypeName returns the last part of a type URL,
+// like MsgCreateValidator for /cosmos.staking.v1beta1.MsgCreateValidator.
+func (msg MsgGeneric) getTypeName() string {
+	parts := strings.Split(msg.TypeUrl, ".")
+	return parts[len(parts)-1]
+}
+
+// getStringFields returns the values of the given top-level string fields of the message JSON.
+func (msg MsgGeneric) getStringFields(keys ...string) []string {
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(msg.Body), &body); err != nil {
+		return []string{}
+	}
+
+	values := []string{}
+	for _, key := range keys {
+		if value, ok := body[key].(string); ok && value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func ParseMsgGeneric(message *cosmosTypes.Any, chain *Chain, block int64) Msg {
+	log.Info().
+		Str("type", message.TypeUrl).
+		Int("size", len(message.Value)).
+		Msg("MsgGeneric")
+
+	return MsgGeneric{
+		TypeUrl: message.TypeUrl,
+		Body:    getMsgJSON(message),
+	}
+}
+
+func getMsgJSON(message *cosmosTypes.Any) string {
+	var msg sdk.Msg
+	if err := interfaceRegistry.UnpackAny(message, &msg); err != nil {
+		log.Warn().Err(err).Str("type", message.TypeUrl).Msg("Could not decode message")
+		return fmt.Sprintf("Could not decode message, %d bytes", len(message.Value))
+	}
+
+	bytesJSON, err := protoCodec.MarshalJSON(msg)
+	if err != nil {
+		log.Warn().Err(err).Str("type", message.TypeUrl).Msg("Could not serialize message to JSON")
+		return fmt.Sprintf("Could not serialize message, %d bytes", len(message.Value))
+	}
+
+	var buffer bytes.Buffer
+	if err := json.Indent(&buffer, bytesJSON, "", "  "); err != nil {
+		return string(bytesJSON)
+	}
+
+	return buffer.String()
+}
+
+func truncateString(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	return string(runes[:length]) + "\n..."
+}
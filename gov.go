@@ -9,6 +9,11 @@ import (
 	"github.com/gogo/protobuf/proto"
 )
 
+func init() {
+	RegisterMsgParser("/cosmos.gov.v1beta1.MsgVote", ParseMsgVote)
+	RegisterMsgParser("/cosmos.gov.v1beta1.MsgSubmitProposal", ParseMsgSubmitProposal)
+}
+
 type MsgVote struct {
 	ProposalId uint64
 	Voter      string
@@ -34,7 +39,30 @@ func (msg MsgVote) Serialize(serializer Serializer) string {
 	)
 }
 
-func ParseMsgVote(message *cosmosTypes.Any) MsgVote {
+func (msg MsgVote) Summary(chain *Chain) string {
+	return fmt.Sprintf(
+		"Vote %s on proposal #%d by %s",
+		msg.Option,
+		msg.ProposalId,
+		chain.getWalletSummary(msg.Voter),
+	)
+}
+
+type JSONMsgVote struct {
+	ProposalId uint64      `json:"proposal_id"`
+	Voter      JSONAddress `json:"voter"`
+	Option     string      `json:"option"`
+}
+
+func (msg MsgVote) SerializeJSON(chain *Chain) interface{} {
+	return JSONMsgVote{
+		ProposalId: msg.ProposalId,
+		Voter:      chain.getJSONAddress(msg.Voter),
+		Option:     msg.Option,
+	}
+}
+
+func ParseMsgVote(message *cosmosTypes.Any, chain *Chain, block int64) Msg {
 	var parsedMessage cosmosGovTypes.MsgVote
 	if err := proto.Unmarshal(message.Value, &parsedMessage); err != nil {
 		log.Error().Err(err).Msg("Could not parse MsgVote")
@@ -64,7 +92,7 @@ func (msg MsgSubmitProposal) Empty() bool {
 	return msg.Title == ""
 }
 
-func ParseMsgSubmitProposal(message *cosmosTypes.Any, block int64) MsgSubmitProposal {
+func ParseMsgSubmitProposal(message *cosmosTypes.Any, chain *Chain, block int64) Msg {
 	var parsedMessage cosmosGovTypes.MsgSubmitProposal
 	if err := proto.Unmarshal(message.Value, &parsedMessage); err != nil {
 		log.Error().Err(err).Msg("Could not parse MsgSubmitProposal")
@@ -88,11 +116,11 @@ func (msg MsgSubmitProposal) Serialize(serializer Serializer) string {
 	var sb strings.Builder
 
 	sb.WriteString(serializer.StrongSerializer("New proposal") + "\n")
-	sb.WriteString(serializer.LinksSerializer(makeMintscanProposalsLink(), "Mintscan") + "\n")
+	sb.WriteString(serializer.LinksSerializer(serializer.Chain.makeMintscanProposalsLink(), "Mintscan") + "\n")
 
 	sb.WriteString(fmt.Sprintf("%s %s\n",
 		serializer.StrongSerializer("Proposer:"),
-		serializer.LinksSerializer(makeMintscanAccountLink(msg.Proposer), msg.Proposer),
+		serializer.LinksSerializer(serializer.Chain.makeMintscanAccountLink(msg.Proposer), msg.Proposer),
 	))
 
 	sb.WriteString(fmt.Sprintf("%s %s\n",
@@ -107,3 +135,21 @@ func (msg MsgSubmitProposal) Serialize(serializer Serializer) string {
 
 	return sb.String()
 }
+
+func (msg MsgSubmitProposal) Summary(chain *Chain) string {
+	return fmt.Sprintf("Submit proposal \"%s\"", msg.Title)
+}
+
+type JSONMsgSubmitProposal struct {
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Proposer    JSONAddress `json:"proposer"`
+}
+
+func (msg MsgSubmitProposal) SerializeJSON(chain *Chain) interface{} {
+	return JSONMsgSubmitProposal{
+		Title:       msg.Title,
+		Description: msg.Description,
+		Proposer:    chain.getJSONAddress(msg.Proposer),
+	}
+}
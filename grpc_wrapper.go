@@ -2,17 +2,23 @@ package main
 
 import (
 	"context"
-	"math"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
 	cosmostypes "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
 	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	querytypes "github.com/cosmos/cosmos-sdk/types/query"
 	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
 	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	ibctypes "github.com/cosmos/ibc-go/modules/apps/transfer/types"
 )
 
 type GrpcWrapper struct {
@@ -21,10 +27,15 @@ type GrpcWrapper struct {
 }
 
 func InitGrpcWrapper(nodeAddress string) *GrpcWrapper {
-	grpcConn, err := grpc.Dial(
-		NodeAddress,
-		grpc.WithInsecure(),
-	)
+	options := []grpc.DialOption{grpc.WithInsecure()}
+
+	if grpcReplayer != nil {
+		options = append(options, grpc.WithUnaryInterceptor(grpcReplayer.getGrpcInterceptor(nodeAddress)))
+	} else if responseRecorder != nil {
+		options = append(options, grpc.WithUnaryInterceptor(responseRecorder.getGrpcInterceptor(nodeAddress)))
+	}
+
+	grpcConn, err := grpc.Dial(nodeAddress, options...)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Cannot connect to gRPC node")
 	}
@@ -49,6 +60,31 @@ func (w *GrpcWrapper) getValidator(address string) (stakingtypes.Validator, erro
 	return validatorResponse.Validator, err
 }
 
+// getAccountPrefix returns the bech32 prefix of the chain's accounts. There's no query for it,
+// so it's taken from a validator address, like cosmosvaloper1... for cosmos.
+func (w *GrpcWrapper) getAccountPrefix() (string, error) {
+	stakingClient := stakingtypes.NewQueryClient(w.grpcConn)
+	response, err := stakingClient.Validators(
+		context.Background(),
+		&stakingtypes.QueryValidatorsRequest{Pagination: &querytypes.PageRequest{Limit: 1}},
+	)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(response.Validators) == 0 {
+		return "", fmt.Errorf("no validators found")
+	}
+
+	prefix, _, err := bech32.DecodeAndConvert(response.Validators[0].OperatorAddress)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(prefix, cosmostypes.PrefixValidator+cosmostypes.PrefixOperator), nil
+}
+
 func (w *GrpcWrapper) getValidatorCommissionAtBlock(address string, block int64) (cosmostypes.DecCoins, error) {
 	distributionClient := distributiontypes.NewQueryClient(w.grpcConn)
 	response, err := distributionClient.ValidatorCommission(
@@ -80,46 +116,100 @@ func (w *GrpcWrapper) getDelegatorRewardsAtBlock(validator string, delegator str
 	return response.Rewards, nil
 }
 
-func (w *GrpcWrapper) setDenom() {
-	// if --denom and --denom-coefficient are both provided, use them
-	// instead of fetching them via gRPC. Can be useful for networks like osmosis.
-	if Denom != "" && DenomCoefficient != 0 {
-		log.Info().
-			Str("denom", Denom).
-			Float64("coefficient", DenomCoefficient).
-			Msg("Using provided denom and coefficient.")
-		return
+func (w *GrpcWrapper) getDenomMetadata(denom string) (banktypes.Metadata, error) {
+	bankClient := banktypes.NewQueryClient(w.grpcConn)
+	response, err := bankClient.DenomMetadata(
+		context.Background(),
+		&banktypes.QueryDenomMetadataRequest{Denom: denom},
+	)
+
+	if err != nil {
+		return banktypes.Metadata{}, err
 	}
 
-	bankClient := banktypes.NewQueryClient(w.grpcConn)
-	denoms, err := bankClient.DenomsMetadata(
+	return response.Metadata, nil
+}
+
+// getDenomTrace returns the path and the base denom of an IBC token, the hash is without "ibc/".
+func (w *GrpcWrapper) getDenomTrace(hash string) (ibctypes.DenomTrace, error) {
+	transferClient := ibctypes.NewQueryClient(w.grpcConn)
+	response, err := transferClient.DenomTrace(
 		context.Background(),
-		&banktypes.QueryDenomsMetadataRequest{},
+		&ibctypes.QueryDenomTraceRequest{Hash: hash},
 	)
 
 	if err != nil {
-		log.Fatal().Err(err).Msg("Error querying denom")
+		return ibctypes.DenomTrace{}, err
 	}
 
-	metadata := denoms.Metadatas[0] // always using the first one
-	if Denom == "" {                // using display currency
-		Denom = metadata.Display
+	return *response.DenomTrace, nil
+}
+
+func (w *GrpcWrapper) getBlockTime(height int64) (time.Time, error) {
+	tmClient := tmservice.NewServiceClient(w.grpcConn)
+	response, err := tmClient.GetBlockByHeight(
+		context.Background(),
+		&tmservice.GetBlockByHeightRequest{Height: height},
+	)
+
+	if err != nil {
+		return time.Time{}, err
 	}
 
-	for _, unit := range metadata.DenomUnits {
-		log.Debug().
-			Str("denom", unit.Denom).
-			Uint32("exponent", unit.Exponent).
-			Msg("Denom info")
-		if unit.Denom == Denom {
-			DenomCoefficient = math.Pow10(int(unit.Exponent))
-			log.Info().
-				Str("denom", Denom).
-				Float64("coefficient", DenomCoefficient).
-				Msg("Got denom info")
-			return
+	return response.Block.Header.Time, nil
+}
+
+// getAllDenomsMetadata returns the metadata of all the tokens, going through all the pages.
+func (w *GrpcWrapper) getAllDenomsMetadata() ([]banktypes.Metadata, error) {
+	bankClient := banktypes.NewQueryClient(w.grpcConn)
+	metadatas := []banktypes.Metadata{}
+	var nextKey []byte
+
+	for {
+		response, err := bankClient.DenomsMetadata(
+			context.Background(),
+			&banktypes.QueryDenomsMetadataRequest{
+				Pagination: &querytypes.PageRequest{Key: nextKey},
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		metadatas = append(metadatas, response.Metadatas...)
+
+		if response.Pagination == nil || len(response.Pagination.NextKey) == 0 {
+			return metadatas, nil
 		}
+
+		nextKey = response.Pagination.NextKey
+	}
+}
+
+func (w *GrpcWrapper) getBondDenom() (string, error) {
+	stakingClient := stakingtypes.NewQueryClient(w.grpcConn)
+	response, err := stakingClient.Params(
+		context.Background(),
+		&stakingtypes.QueryParamsRequest{},
+	)
+
+	if err != nil {
+		return "", err
+	}
+
+	return response.Params.BondDenom, nil
+}
+
+func (w *GrpcWrapper) getBalance(address string, denom string) (cosmostypes.Int, error) {
+	bankClient := banktypes.NewQueryClient(w.grpcConn)
+	response, err := bankClient.Balance(
+		context.Background(),
+		&banktypes.QueryBalanceRequest{Address: address, Denom: denom},
+	)
+
+	if err != nil {
+		return cosmostypes.Int{}, err
 	}
 
-	log.Fatal().Msg("Could not find the denom info")
+	return response.Balance.Amount, nil
 }
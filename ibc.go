@@ -1,22 +1,28 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	cosmosTypes "github.com/cosmos/cosmos-sdk/codec/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	ibcTypes "github.com/cosmos/ibc-go/modules/apps/transfer/types"
 	ibcChannelTypes "github.com/cosmos/ibc-go/modules/core/04-channel/types"
 	"github.com/gogo/protobuf/proto"
 )
 
+func init() {
+	RegisterMsgParser("/ibc.applications.transfer.v1.MsgTransfer", ParseMsgIbcTransfer)
+	RegisterMsgParser("/ibc.core.channel.v1.MsgRecvPacket", ParseMsgIbcRecvPacket)
+}
+
 type MsgIbcTransfer struct {
 	FromAddress string
 	ToAddress   string
 	SrcPort     string
 	SrcChannel  string
-	Amount      float64
-	Denom       string
+	Token       Coin
 }
 
 func (msg MsgIbcTransfer) Empty() bool {
@@ -24,24 +30,17 @@ func (msg MsgIbcTransfer) Empty() bool {
 }
 
 func (msg MsgIbcTransfer) Serialize(serializer Serializer) string {
-	fromLabel, fromLabelFound := labelsConfigManager.getWalletLabel(msg.FromAddress)
-	toLabel, toLabelFound := labelsConfigManager.getWalletLabel(msg.ToAddress)
+	fromLabel, fromLabelFound := serializer.Chain.LabelsConfigManager.getWalletLabel(msg.FromAddress)
+	toLabel, toLabelFound := serializer.Chain.LabelsConfigManager.getWalletLabel(msg.ToAddress)
 
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf("%s\n", serializer.StrongSerializer("IBC transfer")))
-
-	if msg.Denom == BaseDenom {
-		// for native tokens
-		sb.WriteString(serializer.getTokensMaybeWithDollarPrice(msg.Amount/DenomCoefficient, Denom) + "\n")
-	} else {
-		// for non-native tokens, like ibc/xxxxxx
-		sb.WriteString(serializer.getTokensFormatted(msg.Amount, msg.Denom) + "\n")
-	}
+	sb.WriteString(serializer.getCoinMaybeWithFiatPrice(msg.Token) + "\n")
 
 	sb.WriteString(fmt.Sprintf(`%s %s`,
 		serializer.StrongSerializer("From:"),
-		serializer.LinksSerializer(makeMintscanAccountLink(msg.FromAddress), msg.FromAddress),
+		serializer.LinksSerializer(serializer.Chain.makeMintscanAccountLink(msg.FromAddress), msg.FromAddress),
 	))
 
 	if fromLabelFound {
@@ -55,7 +54,7 @@ func (msg MsgIbcTransfer) Serialize(serializer Serializer) string {
 
 	sb.WriteString(fmt.Sprintf(`%s %s`,
 		serializer.StrongSerializer("To:"),
-		serializer.LinksSerializer(makeMintscanAccountLink(msg.ToAddress), msg.ToAddress),
+		serializer.LinksSerializer(serializer.Chain.makeMintscanAccountLink(msg.ToAddress), msg.ToAddress),
 	))
 
 	if toLabelFound {
@@ -75,7 +74,35 @@ func (msg MsgIbcTransfer) Serialize(serializer Serializer) string {
 	return sb.String()
 }
 
-func ParseMsgIbcTransfer(message *cosmosTypes.Any) MsgIbcTransfer {
+func (msg MsgIbcTransfer) Summary(chain *Chain) string {
+	return fmt.Sprintf(
+		"IBC transfer %s from %s to %s via %s",
+		getTokensSummary(msg.Token.Amount, msg.Token.DisplayDenom()),
+		chain.getWalletSummary(msg.FromAddress),
+		msg.ToAddress,
+		msg.SrcChannel,
+	)
+}
+
+type JSONMsgIbcTransfer struct {
+	From       JSONAddress `json:"from"`
+	To         JSONAddress `json:"to"`
+	SrcPort    string      `json:"src_port"`
+	SrcChannel string      `json:"src_channel"`
+	Amount     JSONAmount  `json:"amount"`
+}
+
+func (msg MsgIbcTransfer) SerializeJSON(chain *Chain) interface{} {
+	return JSONMsgIbcTransfer{
+		From:       chain.getJSONAddress(msg.FromAddress),
+		To:         chain.getJSONAddress(msg.ToAddress),
+		SrcPort:    msg.SrcPort,
+		SrcChannel: msg.SrcChannel,
+		Amount:     chain.getJSONCoin(msg.Token),
+	}
+}
+
+func ParseMsgIbcTransfer(message *cosmosTypes.Any, chain *Chain, block int64) Msg {
 	var parsedMessage ibcTypes.MsgTransfer
 	if err := proto.Unmarshal(message.Value, &parsedMessage); err != nil {
 		log.Error().Err(err).Msg("Could not parse MsgIbcTransfer")
@@ -85,8 +112,7 @@ func ParseMsgIbcTransfer(message *cosmosTypes.Any) MsgIbcTransfer {
 	log.Info().
 		Str("from", parsedMessage.Sender).
 		Str("to", parsedMessage.Receiver).
-		Float64("amount", parsedMessage.Token.Amount.ToDec().MustFloat64()).
-		Str("denom", parsedMessage.Token.Denom).
+		Str("amount", parsedMessage.Token.String()).
 		Msg("MsgIbcTransfer")
 
 	return MsgIbcTransfer{
@@ -94,8 +120,7 @@ func ParseMsgIbcTransfer(message *cosmosTypes.Any) MsgIbcTransfer {
 		ToAddress:   parsedMessage.Receiver,
 		SrcPort:     parsedMessage.SourcePort,
 		SrcChannel:  parsedMessage.SourceChannel,
-		Amount:      parsedMessage.Token.Amount.ToDec().MustFloat64(),
-		Denom:       parsedMessage.Token.Denom,
+		Token:       chain.getCoin(parsedMessage.Token),
 	}
 }
 
@@ -107,8 +132,7 @@ type MsgIbcRecvPacket struct {
 	SrcChannel  string
 	DstPort     string
 	DstChannel  string
-	Amount      float64
-	Denom       string
+	Token       *Coin
 }
 
 func (msg MsgIbcRecvPacket) Empty() bool {
@@ -116,8 +140,8 @@ func (msg MsgIbcRecvPacket) Empty() bool {
 }
 
 func (msg MsgIbcRecvPacket) Serialize(serializer Serializer) string {
-	fromLabel, fromLabelFound := labelsConfigManager.getWalletLabel(msg.FromAddress)
-	toLabel, toLabelFound := labelsConfigManager.getWalletLabel(msg.ToAddress)
+	fromLabel, fromLabelFound := serializer.Chain.LabelsConfigManager.getWalletLabel(msg.FromAddress)
+	toLabel, toLabelFound := serializer.Chain.LabelsConfigManager.getWalletLabel(msg.ToAddress)
 
 	var sb strings.Builder
 
@@ -125,23 +149,17 @@ func (msg MsgIbcRecvPacket) Serialize(serializer Serializer) string {
 
 	sb.WriteString(fmt.Sprintf("%s %s\n",
 		serializer.StrongSerializer("Signer:"),
-		serializer.LinksSerializer(makeMintscanAccountLink(msg.Signer), msg.Signer),
+		serializer.LinksSerializer(serializer.Chain.makeMintscanAccountLink(msg.Signer), msg.Signer),
 	))
 
-	if msg.Denom != "" {
-		if msg.Denom == BaseDenom {
-			// for native tokens
-			sb.WriteString(serializer.getTokensMaybeWithDollarPrice(msg.Amount/DenomCoefficient, Denom) + "\n")
-		} else {
-			// for non-native tokens, like ibc/xxxxxx
-			sb.WriteString(serializer.getTokensFormatted(msg.Amount, msg.Denom) + "\n")
-		}
+	if msg.Token != nil {
+		sb.WriteString(serializer.getCoinMaybeWithFiatPrice(*msg.Token) + "\n")
 	}
 
 	if msg.FromAddress != "" {
 		sb.WriteString(fmt.Sprintf(`%s %s`,
 			serializer.StrongSerializer("From:"),
-			serializer.LinksSerializer(makeMintscanAccountLink(msg.FromAddress), msg.FromAddress),
+			serializer.LinksSerializer(serializer.Chain.makeMintscanAccountLink(msg.FromAddress), msg.FromAddress),
 		))
 
 		if fromLabelFound {
@@ -157,7 +175,7 @@ func (msg MsgIbcRecvPacket) Serialize(serializer Serializer) string {
 	if msg.ToAddress != "" {
 		sb.WriteString(fmt.Sprintf(`%s %s`,
 			serializer.StrongSerializer("To:"),
-			serializer.LinksSerializer(makeMintscanAccountLink(msg.ToAddress), msg.ToAddress),
+			serializer.LinksSerializer(serializer.Chain.makeMintscanAccountLink(msg.ToAddress), msg.ToAddress),
 		))
 
 		if toLabelFound {
@@ -179,7 +197,79 @@ func (msg MsgIbcRecvPacket) Serialize(serializer Serializer) string {
 	return sb.String()
 }
 
-func ParseMsgIbcRecvPacket(message *cosmosTypes.Any) MsgIbcRecvPacket {
+func (msg MsgIbcRecvPacket) Summary(chain *Chain) string {
+	if msg.Token == nil {
+		return fmt.Sprintf("Receive IBC packet via %s", msg.DstChannel)
+	}
+
+	return fmt.Sprintf(
+		"Receive IBC transfer of %s to %s via %s",
+		getTokensSummary(msg.Token.Amount, msg.Token.DisplayDenom()),
+		chain.getWalletSummary(msg.ToAddress),
+		msg.DstChannel,
+	)
+}
+
+type JSONMsgIbcRecvPacket struct {
+	Signer     JSONAddress  `json:"signer"`
+	From       *JSONAddress `json:"from,omitempty"`
+	To         *JSONAddress `json:"to,omitempty"`
+	SrcPort    string       `json:"src_port"`
+	SrcChannel string       `json:"src_channel"`
+	DstPort    string       `json:"dst_port"`
+	DstChannel string       `json:"dst_channel"`
+	Amount     *JSONAmount  `json:"amount,omitempty"`
+}
+
+func (msg MsgIbcRecvPacket) SerializeJSON(chain *Chain) interface{} {
+	result := JSONMsgIbcRecvPacket{
+		Signer:     chain.getJSONAddress(msg.Signer),
+		SrcPort:    msg.SrcPort,
+		SrcChannel: msg.SrcChannel,
+		DstPort:    msg.DstPort,
+		DstChannel: msg.DstChannel,
+	}
+
+	if msg.FromAddress != "" {
+		from := chain.getJSONAddress(msg.FromAddress)
+		result.From = &from
+	}
+
+	if msg.ToAddress != "" {
+		to := chain.getJSONAddress(msg.ToAddress)
+		result.To = &to
+	}
+
+	if msg.Token != nil {
+		amount := chain.getJSONCoin(*msg.Token)
+		result.Amount = &amount
+	}
+
+	return result
+}
+
+// getReceivedDenom returns the denom the received tokens have on this chain. The packet has
+// the denom as it's on the sender chain, so the tokens that are coming back lose the prefix
+// of the channel they were sent through, and the other ones get this channel's prefix.
+func getReceivedDenom(srcPort string, srcChannel string, dstPort string, dstChannel string, denom string) string {
+	if ibcTypes.ReceiverChainIsSource(srcPort, srcChannel, denom) {
+		unprefixedDenom := strings.TrimPrefix(denom, ibcTypes.GetDenomPrefix(srcPort, srcChannel))
+		return ibcTypes.ParseDenomTrace(unprefixedDenom).IBCDenom()
+	}
+
+	return ibcTypes.ParseDenomTrace(ibcTypes.GetPrefixedDenom(dstPort, dstChannel, denom)).IBCDenom()
+}
+
+// ibcTokenPacketData is the ICS-20 packet data. It's not parsed into ibc-go's FungibleTokenPacketData,
+// as its amount is uint64 there, which overflows for the tokens with 18 decimals.
+type ibcTokenPacketData struct {
+	Denom    string `json:"denom"`
+	Amount   string `json:"amount"`
+	Sender   string `json:"sender"`
+	Receiver string `json:"receiver"`
+}
+
+func ParseMsgIbcRecvPacket(message *cosmosTypes.Any, chain *Chain, block int64) Msg {
 	var parsedMessage ibcChannelTypes.MsgRecvPacket
 	if err := proto.Unmarshal(message.Value, &parsedMessage); err != nil {
 		log.Error().Err(err).Msg("Could not parse MsgIbcRecvPacket")
@@ -194,22 +284,30 @@ func ParseMsgIbcRecvPacket(message *cosmosTypes.Any) MsgIbcRecvPacket {
 		DstChannel: parsedMessage.Packet.DestinationChannel,
 	}
 
-	var data ibcTypes.FungibleTokenPacketData
-	if err := ibcTypes.ModuleCdc.UnmarshalJSON(parsedMessage.Packet.Data, &data); err != nil {
+	var data ibcTokenPacketData
+	if err := json.Unmarshal(parsedMessage.Packet.Data, &data); err != nil {
 		log.Warn().Err(err).Msg("Could not parse MsgIbcRecvPacket data")
 		return result
+	}
+
+	result.FromAddress = data.Sender
+	result.ToAddress = data.Receiver
+
+	if amount, ok := sdk.NewIntFromString(data.Amount); ok {
+		token := chain.getCoin(sdk.Coin{
+			Denom:  getReceivedDenom(result.SrcPort, result.SrcChannel, result.DstPort, result.DstChannel, data.Denom),
+			Amount: amount,
+		})
+		result.Token = &token
 	} else {
-		result.FromAddress = data.Sender
-		result.ToAddress = data.Receiver
-		result.Amount = float64(data.Amount)
-		result.Denom = data.Denom
+		log.Warn().Str("amount", data.Amount).Msg("Could not parse MsgIbcRecvPacket amount")
 	}
 
 	log.Info().
 		Str("signer", parsedMessage.Signer).
 		Str("from", data.Sender).
 		Str("to", data.Receiver).
-		Uint64("amount", data.Amount).
+		Str("amount", data.Amount).
 		Str("denom", data.Denom).
 		Msg("MsgIbcRecvPacket")
 
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+)
+
+func getIbcTestDenom(path string) string {
+	return fmt.Sprintf("ibc/%X", sha256.Sum256([]byte(path)))
+}
+
+func TestGetReceivedDenom(t *testing.T) {
+	tests := []struct {
+		name       string
+		srcPort    string
+		srcChannel string
+		dstPort    string
+		dstChannel string
+		denom      string
+		expected   string
+	}{
+		// the sender chain is the source, so the tokens get this chain's channel prefix
+		{"native", "transfer", "channel-141", "transfer", "channel-0", "uosmo", getIbcTestDenom("transfer/channel-0/uosmo")},
+		{"native with slashes", "transfer", "channel-141", "transfer", "channel-0", "gamm/pool/1", getIbcTestDenom("transfer/channel-0/gamm/pool/1")},
+		{"multihop", "transfer", "channel-141", "transfer", "channel-0", "transfer/channel-5/uluna", getIbcTestDenom("transfer/channel-0/transfer/channel-5/uluna")},
+
+		// this chain is the source, so the tokens lose the prefix of the channel they came back through
+		{"returning", "transfer", "channel-141", "transfer", "channel-0", "transfer/channel-141/uatom", "uatom"},
+		{"returning multihop", "transfer", "channel-141", "transfer", "channel-0", "transfer/channel-141/transfer/channel-5/uluna", getIbcTestDenom("transfer/channel-5/uluna")},
+
+		// the prefix of another channel is not this chain's
+		{"other channel", "transfer", "channel-141", "transfer", "channel-0", "transfer/channel-14/uatom", getIbcTestDenom("transfer/channel-0/transfer/channel-14/uatom")},
+	}
+
+	if expected := "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2"; getIbcTestDenom("transfer/channel-0/uatom") != expected {
+		t.Fatalf("expected the ATOM denom on Osmosis to be %s", expected)
+	}
+
+	for _, test := range tests {
+		result := getReceivedDenom(test.srcPort, test.srcChannel, test.dstPort, test.dstChannel, test.denom)
+		if result != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, result)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"os/signal"
@@ -16,9 +15,9 @@ import (
 	"github.com/spf13/viper"
 	json "github.com/tendermint/tendermint/libs/json"
 
+	abciTypes "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto/tmhash"
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
-	tmclient "github.com/tendermint/tendermint/rpc/jsonrpc/client"
 	jsonRpcTypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
 	events "github.com/tendermint/tendermint/types"
 
@@ -29,47 +28,115 @@ import (
 var (
 	ConfigPath       string
 	LabelsConfigPath string
+	AssetLists       []string
 
 	LogLevel          string
+	RecordPath        string
+	DryRun            bool
+	StatePath         string
+	SentTxsTTL        time.Duration
+	SentTxsMaxCount   int
 	Queries           []string
+	FilterExpression  string
+	MaxSubscriptions  int
+	MaxBackfillBlocks int64
 	MintscanProject   string
 	CoingeckoCurrency string
+	FiatCurrency      string
+	PricesFile        string
 
 	TelegramToken              string
 	TelegramChat               int
+	TelegramAdmins             []int
 	TelegramSetAliasCommand    string
 	TelegramClearAliasCommand  string
 	TelegramListAliasesCommand string
+	TelegramListQueriesCommand string
+	TelegramAddQueryCommand    string
+	TelegramRemoveQueryCommand string
+	TelegramFailedTxsMode      string
+	TelegramTxFields           []string
 
 	SlackToken              string
 	SlackChat               string
 	SlackSigningSecret      string
 	SlackListenAddress      string
+	SlackAdmins             []string
 	SlackSetAliasCommand    string
 	SlackClearAliasCommand  string
 	SlackListAliasesCommand string
+	SlackListQueriesCommand string
+	SlackAddQueryCommand    string
+	SlackRemoveQueryCommand string
+	SlackFailedTxsMode      string
+	SlackTxFields           []string
+
+	DiscordToken              string
+	DiscordChannel            string
+	DiscordWebhookURL         string
+	DiscordGuild              string
+	DiscordAdmins             []string
+	DiscordSetAliasCommand    string
+	DiscordClearAliasCommand  string
+	DiscordListAliasesCommand string
+	DiscordFailedTxsMode      string
+	DiscordTxFields           []string
+
+	MatrixHomeserver         string
+	MatrixAccessToken        string
+	MatrixRoom               string
+	MatrixAdmins             []string
+	MatrixSetAliasCommand    string
+	MatrixClearAliasCommand  string
+	MatrixListAliasesCommand string
+	MatrixFailedTxsMode      string
+	MatrixTxFields           []string
+
+	EmailHost          string
+	EmailPort          int
+	EmailUsername      string
+	EmailPassword      string
+	EmailTLSMode       string
+	EmailFrom          string
+	EmailTo            []string
+	EmailFailedTxsMode string
+	EmailTxFields      []string
+
+	AlertProviderName  string
+	AlertKey           string
+	AlertURL           string
+	AlertFailedTxsMode string
+
+	WebhookURLs          []string
+	WebhookSecret        string
+	WebhookMaxRetries    int
+	WebhookFailedTxsMode string
+
+	StdoutEnabled       bool
+	StdoutFormat        string
+	StdoutFailedTxsMode string
+	StdoutTxFields      []string
 
 	NodeAddress          string
 	TendermintRpcAddress string
+	UsePolling           bool
+	PollingInterval      time.Duration
 
 	BaseDenom        string
 	Denom            string
 	DenomCoefficient float64
+	DisplayPrecision int
 
 	Printer = message.NewPrinter(language.English)
 
-	reporters []Reporter
+	reporters    []Reporter
+	Chains       []*Chain
+	stateManager *StateManager
 
-	client *tmclient.WSClient
+	responseRecorder *ResponseRecorder
+	grpcReplayer     *GrpcReplayer
 
 	log = zerolog.New(zerolog.ConsoleWriter{Out: os.Stdout}).With().Timestamp().Logger()
-
-	SentTransactions map[string]bool = make(map[string]bool)
-
-	labelsConfigManager *LabelsConfigManager
-	cacheManager        *CacheManager
-	grpcWrapper         *GrpcWrapper
-	coingeckoWrapper    *CoingeckoWrapper
 )
 
 var rootCmd = &cobra.Command{
@@ -129,101 +196,90 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute(cmd *cobra.Command, args []string) {
+	setLogLevel()
+
+	stateManager = NewStateManager(StatePath, SentTxsTTL, SentTxsMaxCount)
+	defer stateManager.Close()
+
+	// before the chains, so the gRPC lookups they do on start are recorded too
+	if RecordPath != "" {
+		responseRecorder = NewResponseRecorder(RecordPath)
+		defer responseRecorder.Close()
+	}
+
+	initChains()
+	defer stopChains()
+
+	initReporters()
+
+	for _, chain := range Chains {
+		go chain.Listen()
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+}
+
+func setLogLevel() {
 	logLevel, err := zerolog.ParseLevel(LogLevel)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Could not parse log level")
 	}
 
 	zerolog.SetGlobalLevel(logLevel)
+}
 
-	grpcWrapper = InitGrpcWrapper(NodeAddress)
-	grpcWrapper.setDenom()
-	defer grpcWrapper.CloseConnection()
-
-	labelsConfigManager = initLabelsConfig(LabelsConfigPath)
-	coingeckoWrapper = NewCoingeckoWrapper(CoingeckoCurrency)
-	cacheManager = NewCacheManager(grpcWrapper, coingeckoWrapper)
-
-	reporters = []Reporter{
-		&TelegramReporter{
-			TelegramToken:              TelegramToken,
-			TelegramChat:               TelegramChat,
-			TelegramSetAliasCommand:    TelegramSetAliasCommand,
-			TelegramClearAliasCommand:  TelegramClearAliasCommand,
-			TelegramListAliasesCommand: TelegramListAliasesCommand,
-			CacheManager:               cacheManager,
-		},
-		&SlackReporter{
-			SlackToken:              SlackToken,
-			SlackChat:               SlackChat,
-			SlackSigningSecret:      SlackSigningSecret,
-			SlackListenAddress:      SlackListenAddress,
-			SlackSetAliasCommand:    SlackSetAliasCommand,
-			SlackClearAliasCommand:  SlackClearAliasCommand,
-			SlackListAliasesCommand: SlackListAliasesCommand,
-			CacheManager:            cacheManager,
-		},
-	}
-
-	for _, reporter := range reporters {
-		reporter.Init()
+func initChains() {
+	for _, config := range loadChainConfigs() {
+		chain := NewChain(config)
+		chain.Init()
 
-		if reporter.Enabled() {
-			log.Info().Str("name", reporter.Name()).Msg("Init reporter")
-		}
+		Chains = append(Chains, chain)
 	}
+}
 
-	client, err = tmclient.NewWS(
-		TendermintRpcAddress,
-		"/websocket",
-		tmclient.PingPeriod(5*time.Second),
-		tmclient.OnReconnect(func() {
-			log.Info().Msg("Reconnected to websocket...")
-			subscribeToUpdates()
-		}),
-	)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to create a client")
-		os.Exit(1)
+func stopChains() {
+	for _, chain := range Chains {
+		chain.Stop()
 	}
+}
 
-	err = client.Start()
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to start a client")
-		os.Exit(1)
+func initReporters() {
+	configs := loadReporterConfigs()
+
+	if DryRun {
+		log.Info().Msg("Running in dry-run mode, only printing reports to stdout.")
+		configs = []ReporterConfig{defaultReporterConfig("stdout")}
 	}
-	defer client.Stop() // nolint
 
-	subscribeToUpdates()
+	reporters = make([]Reporter, len(configs))
+	for index, config := range configs {
+		reporters[index] = config.newReporter()
+	}
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	for _, reporter := range reporters {
+		reporter.Init()
 
-	for {
-		select {
-		case result := <-client.ResponsesCh:
-			processResponse(result)
-		case <-quit:
-			os.Exit(0)
+		if reporter.Enabled() {
+			log.Info().Str("name", reporter.Name()).Msg("Init reporter")
 		}
 	}
-}
 
-func subscribeToUpdates() {
-	for _, query := range Queries {
-		if err := client.Subscribe(context.Background(), query); err != nil {
-			log.Fatal().Err(err).Str("query", query).Msg("Failed to subscribe to query")
-		}
+	checkReporterQueries()
+}
 
-		log.Info().Str("query", query).Msg("Listening for incoming transactions")
+func (c *Chain) processResponse(result jsonRpcTypes.RPCResponse) {
+	if responseRecorder != nil {
+		responseRecorder.Record(c.Name, result)
 	}
-}
 
-func processResponse(result jsonRpcTypes.RPCResponse) {
-	report := generateReport(result)
+	c.sendReport(c.generateReport(result))
+}
 
+func (c *Chain) sendReport(report Report) {
 	if report.Empty() {
-		log.Info().Msg("Report is empty, not sending.")
+		log.Info().Str("chain", c.Name).Msg("Report is empty, not sending.")
 		return
 	}
 
@@ -233,20 +289,39 @@ func processResponse(result jsonRpcTypes.RPCResponse) {
 			continue
 		}
 
+		if !shouldReceive(reporter, report.Tx.MatchedQueries) {
+			log.Debug().
+				Str("name", reporter.Name()).
+				Strs("queries", report.Tx.MatchedQueries).
+				Msg("Reporter is not configured to receive txs matching these queries, skipping.")
+			continue
+		}
+
+		if !reporter.Filter().Matches(report) {
+			log.Debug().
+				Str("name", reporter.Name()).
+				Msg("Report does not match the reporter filter, skipping.")
+			continue
+		}
+
+		if !reporter.FailedTxsMode().ShouldReport(report.Tx) {
+			log.Debug().
+				Str("name", reporter.Name()).
+				Bool("failed", report.Tx.Failed()).
+				Msg("Reporter is not configured to send such txs, skipping.")
+			continue
+		}
+
 		log.Info().Str("name", reporter.Name()).Msg("Sending a report to reporter...")
 		if err := reporter.SendReport(report); err != nil {
 			log.Error().Err(err).Str("name", reporter.Name()).Msg("Could not send message")
 		}
 	}
 
-	cacheManager.clearCache()
+	c.CacheManager.clearCache()
 }
 
-func generateReport(result jsonRpcTypes.RPCResponse) Report {
-	report := Report{
-		Msgs: []Msg{},
-	}
-
+func (c *Chain) generateReport(result jsonRpcTypes.RPCResponse) Report {
 	if result.Error != nil && result.Error.Message != "" {
 		log.Error().Str("msg", result.Error.Error()).Msg("Got error in RPC response")
 		return Report{}
@@ -263,7 +338,15 @@ func generateReport(result jsonRpcTypes.RPCResponse) Report {
 		return Report{}
 	}
 
-	txResult := resultEvent.Data.(events.EventDataTx).TxResult
+	return c.generateReportFromTxResult(resultEvent.Data.(events.EventDataTx).TxResult)
+}
+
+func (c *Chain) generateReportFromTxResult(txResult abciTypes.TxResult) Report {
+	report := Report{
+		Chain: c,
+		Msgs:  []Msg{},
+	}
+
 	txHash := fmt.Sprintf("%X", tmhash.Sum(txResult.Tx))
 	var tx tx.Tx
 
@@ -271,57 +354,52 @@ func generateReport(result jsonRpcTypes.RPCResponse) Report {
 		log.Error().Err(err).Msg("Could not parse tx")
 	}
 
-	txMessages := tx.GetBody().GetMessages()
-	report.Tx = parseTx(txResult)
-
-	if _, ok := SentTransactions[txHash]; ok {
+	if stateManager.isTxSent(c.Name, txHash) {
 		log.Debug().Str("hash", txHash).Msg("Transaction already sent, skipping.")
 		return Report{}
 	}
 
+	txEvents := getTxEvents(txResult, txHash)
+	if _, matchLocally := c.getSubscriptions(); matchLocally && !c.matchesAnyQuery(txEvents) {
+		log.Trace().Str("chain", c.Name).Str("hash", txHash).Msg("Transaction does not match any query, skipping.")
+		return Report{}
+	}
+
+	txMessages := tx.GetBody().GetMessages()
+	report.Tx = c.parseTx(txResult)
+	report.Tx.MatchedQueries = c.getMatchedQueries(txEvents)
+
 	log.Info().
+		Str("chain", c.Name).
 		Int64("height", txResult.Height).
+		Uint32("code", txResult.Result.Code).
 		Str("memo", tx.GetBody().GetMemo()).
 		Str("hash", txHash).
 		Int("len", len(txMessages)).
+		Strs("queries", report.Tx.MatchedQueries).
 		Msg("Got transaction")
 
 	for _, message := range txMessages {
-		var msg Msg
-
-		switch message.TypeUrl {
-		case "/cosmos.bank.v1beta1.MsgSend":
-			msg = ParseMsgSend(message)
-		case "/cosmos.gov.v1beta1.MsgVote":
-			msg = ParseMsgVote(message)
-		case "/cosmos.gov.v1beta1.MsgSubmitProposal":
-			msg = ParseMsgSubmitProposal(message, txResult.Height)
-		case "/cosmos.staking.v1beta1.MsgDelegate":
-			msg = ParseMsgDelegate(message)
-		case "/cosmos.staking.v1beta1.MsgUndelegate":
-			msg = ParseMsgUndelegate(message)
-		case "/cosmos.staking.v1beta1.MsgBeginRedelegate":
-			msg = ParseMsgBeginRedelegate(message)
-		case "/cosmos.distribution.v1beta1.MsgSetWithdrawAddress":
-			msg = ParseMsgSetWithdrawAddress(message)
-		case "/cosmos.distribution.v1beta1.MsgWithdrawDelegatorReward":
-			msg = ParseMsgWithdrawDelegatorReward(message, txResult.Height)
-		case "/cosmos.distribution.v1beta1.MsgWithdrawValidatorCommission":
-			msg = ParseMsgWithdrawValidatorCommission(message, txResult.Height)
-		case "/ibc.applications.transfer.v1.MsgTransfer":
-			msg = ParseMsgIbcTransfer(message)
-		case "/ibc.core.channel.v1.MsgRecvPacket":
-			msg = ParseMsgIbcRecvPacket(message)
-		default:
-			log.Warn().Str("type", message.TypeUrl).Msg("Got a message which is not supported")
+		parser, found := getMsgParser(message.TypeUrl)
+		if !found {
+			log.Warn().Str("type", message.TypeUrl).Msg("Got a message which is not supported, displaying it as is")
+			parser = ParseMsgGeneric
 		}
 
+		msg := parser(message, c, txResult.Height)
+
 		if msg != nil && !msg.Empty() {
 			report.Msgs = append(report.Msgs, msg)
 		}
 	}
 
-	SentTransactions[txHash] = true
+	stateManager.setTxSent(c.Name, txHash)
+	stateManager.setLastHeight(c.Name, txResult.Height)
+
+	if !c.filter.Matches(report) {
+		log.Info().Str("chain", c.Name).Str("hash", txHash).Msg("Transaction does not match the filter, skipping.")
+		return Report{}
+	}
 
 	return report
 }
@@ -329,30 +407,105 @@ func generateReport(result jsonRpcTypes.RPCResponse) Report {
 func main() {
 	rootCmd.PersistentFlags().StringVar(&ConfigPath, "config", "", "Config file path")
 	rootCmd.PersistentFlags().StringVar(&LabelsConfigPath, "labels-config", "", "Labels config file path")
+	rootCmd.PersistentFlags().StringSliceVar(&AssetLists, "assetlist", []string{}, "Path to the chain-registry assetlist.json file to get the tokens symbols and exponents from")
 	rootCmd.PersistentFlags().StringVar(&BaseDenom, "base-denom", "", "Cosmos coin base denom (like uatom)")
 	rootCmd.PersistentFlags().StringVar(&Denom, "denom", "", "Cosmos coin display denom (like atom)")
 	rootCmd.PersistentFlags().Float64Var(&DenomCoefficient, "denom-coefficient", 1_000_000, "Denom coefficient from base denom to display denom")
+	rootCmd.PersistentFlags().IntVar(&DisplayPrecision, "display-precision", 6, "How many decimal digits to show in amounts, up to 18")
 	rootCmd.PersistentFlags().StringVar(&LogLevel, "log-level", "info", "Logging level")
-	rootCmd.PersistentFlags().StringSliceVar(&Queries, "query", []string{"tx.height > 1"}, "Tx filter to subscribe to")
+	rootCmd.PersistentFlags().StringVar(&RecordPath, "record", "", "Path to the file to write all the websocket responses and gRPC calls to, for replaying them later")
+	rootCmd.PersistentFlags().StringVar(&StatePath, "state-path", "", "Path to the database to persist the sent txs in")
+	rootCmd.PersistentFlags().DurationVar(&SentTxsTTL, "sent-txs-ttl", 24*time.Hour, "How long to remember the sent txs for")
+	rootCmd.PersistentFlags().IntVar(&SentTxsMaxCount, "sent-txs-max-count", 100_000, "How many sent txs to remember at most")
+	rootCmd.PersistentFlags().StringVar(&FilterExpression, "filter", "", "Filter expression the txs should match, like `type == Delegate && usd > 10000`")
+	rootCmd.PersistentFlags().IntVar(&MaxSubscriptions, "max-subscriptions", 5, "How many queries the node allows to subscribe to, all txs are matched locally if there are more")
+	rootCmd.PersistentFlags().Int64Var(&MaxBackfillBlocks, "max-backfill-blocks", 10_000, "How many blocks to search for the missed txs at most, 0 for no limit")
+	rootCmd.PersistentFlags().StringSliceVar(&Queries, "query", []string{DefaultQuery}, "Tx filter to subscribe to")
 
 	rootCmd.PersistentFlags().StringVar(&TelegramToken, "telegram-token", "", "Telegram bot token")
 	rootCmd.PersistentFlags().IntVar(&TelegramChat, "telegram-chat", 0, "Telegram chat or user ID")
+	rootCmd.PersistentFlags().IntSliceVar(&TelegramAdmins, "telegram-admins", []int{}, "Telegram user IDs allowed to run commands outside of the reports chat")
 	rootCmd.PersistentFlags().StringVar(&TelegramSetAliasCommand, "telegram-set-alias-command", "/set_alias", "Telegram slash command to set alias")
 	rootCmd.PersistentFlags().StringVar(&TelegramClearAliasCommand, "telegram-clear-alias-command", "/clear_alias", "Telegram slash command to clear alias")
 	rootCmd.PersistentFlags().StringVar(&TelegramListAliasesCommand, "telegram-list-aliases-command", "/list_aliases", "Telegram slash command to list aliases")
+	rootCmd.PersistentFlags().StringVar(&TelegramListQueriesCommand, "telegram-list-queries-command", "/list_queries", "Telegram slash command to list queries")
+	rootCmd.PersistentFlags().StringVar(&TelegramAddQueryCommand, "telegram-add-query-command", "/add_query", "Telegram slash command to add query")
+	rootCmd.PersistentFlags().StringVar(&TelegramRemoveQueryCommand, "telegram-remove-query-command", "/remove_query", "Telegram slash command to remove query")
+	rootCmd.PersistentFlags().StringVar(&TelegramFailedTxsMode, "telegram-failed-txs", "report", "Whether to send failed txs to Telegram: report, skip or only")
+	rootCmd.PersistentFlags().StringSliceVar(&TelegramTxFields, "telegram-tx-fields", []string{"fee", "gas", "signers", "time"}, "Tx details to show in Telegram: fee, gas, signers, time")
 
 	rootCmd.PersistentFlags().StringVar(&SlackToken, "slack-token", "", "Slack bot token")
 	rootCmd.PersistentFlags().StringVar(&SlackChat, "slack-chat", "", "Slack chat or user ID")
 	rootCmd.PersistentFlags().StringVar(&SlackSigningSecret, "slack-signing-secret", "", "Slack signing secret for slash commands handling")
 	rootCmd.PersistentFlags().StringVar(&SlackListenAddress, "slack-listen-address", ":9500", "An address where Slack slash command handler would be exposed at")
+	rootCmd.PersistentFlags().StringSliceVar(&SlackAdmins, "slack-admins", []string{}, "Slack user IDs allowed to run commands outside of the reports channel")
 	rootCmd.PersistentFlags().StringVar(&SlackSetAliasCommand, "slack-set-alias-command", "/set-alias", "Slack slash command to set alias")
 	rootCmd.PersistentFlags().StringVar(&SlackClearAliasCommand, "slack-clear-alias-command", "/clear-alias", "Slack slash command to clear alias")
 	rootCmd.PersistentFlags().StringVar(&SlackListAliasesCommand, "slack-list-aliases-command", "/list-aliases", "Slack slash command to list aliases")
+	rootCmd.PersistentFlags().StringVar(&SlackListQueriesCommand, "slack-list-queries-command", "/list-queries", "Slack slash command to list queries")
+	rootCmd.PersistentFlags().StringVar(&SlackAddQueryCommand, "slack-add-query-command", "/add-query", "Slack slash command to add query")
+	rootCmd.PersistentFlags().StringVar(&SlackRemoveQueryCommand, "slack-remove-query-command", "/remove-query", "Slack slash command to remove query")
+	rootCmd.PersistentFlags().StringVar(&SlackFailedTxsMode, "slack-failed-txs", "report", "Whether to send failed txs to Slack: report, skip or only")
+	rootCmd.PersistentFlags().StringSliceVar(&SlackTxFields, "slack-tx-fields", []string{"fee", "gas", "signers", "time"}, "Tx details to show in Slack: fee, gas, signers, time")
+
+	rootCmd.PersistentFlags().StringVar(&DiscordToken, "discord-token", "", "Discord bot token")
+	rootCmd.PersistentFlags().StringVar(&DiscordChannel, "discord-channel", "", "Discord channel ID")
+	rootCmd.PersistentFlags().StringVar(&DiscordWebhookURL, "discord-webhook-url", "", "Discord webhook URL, used instead of bot token and channel to send reports")
+	rootCmd.PersistentFlags().StringVar(&DiscordGuild, "discord-guild", "", "Discord server ID to register slash commands in, registered globally if empty")
+	rootCmd.PersistentFlags().StringSliceVar(&DiscordAdmins, "discord-admins", []string{}, "Discord user IDs allowed to run commands outside of the reports channel")
+	rootCmd.PersistentFlags().StringVar(&DiscordSetAliasCommand, "discord-set-alias-command", "set-alias", "Discord slash command to set alias")
+	rootCmd.PersistentFlags().StringVar(&DiscordClearAliasCommand, "discord-clear-alias-command", "clear-alias", "Discord slash command to clear alias")
+	rootCmd.PersistentFlags().StringVar(&DiscordListAliasesCommand, "discord-list-aliases-command", "list-aliases", "Discord slash command to list aliases")
+	rootCmd.PersistentFlags().StringVar(&DiscordFailedTxsMode, "discord-failed-txs", "report", "Whether to send failed txs to Discord: report, skip or only")
+	rootCmd.PersistentFlags().StringSliceVar(&DiscordTxFields, "discord-tx-fields", []string{"fee", "gas", "signers", "time"}, "Tx details to show in Discord: fee, gas, signers, time")
+
+	rootCmd.PersistentFlags().StringVar(&MatrixHomeserver, "matrix-homeserver", "", "Matrix homeserver URL, like https://matrix.org")
+	rootCmd.PersistentFlags().StringVar(&MatrixAccessToken, "matrix-access-token", "", "Matrix bot user access token")
+	rootCmd.PersistentFlags().StringVar(&MatrixRoom, "matrix-room", "", "Matrix room ID or alias to send reports to")
+	rootCmd.PersistentFlags().StringSliceVar(&MatrixAdmins, "matrix-admins", []string{}, "Matrix user IDs allowed to run commands, anyone in the room if not set")
+	rootCmd.PersistentFlags().StringVar(&MatrixSetAliasCommand, "matrix-set-alias-command", "!set-alias", "Matrix command to set alias")
+	rootCmd.PersistentFlags().StringVar(&MatrixClearAliasCommand, "matrix-clear-alias-command", "!clear-alias", "Matrix command to clear alias")
+	rootCmd.PersistentFlags().StringVar(&MatrixListAliasesCommand, "matrix-list-aliases-command", "!list-aliases", "Matrix command to list aliases")
+	rootCmd.PersistentFlags().StringVar(&MatrixFailedTxsMode, "matrix-failed-txs", "report", "Whether to send failed txs to Matrix: report, skip or only")
+	rootCmd.PersistentFlags().StringSliceVar(&MatrixTxFields, "matrix-tx-fields", []string{"fee", "gas", "signers", "time"}, "Tx details to show in Matrix: fee, gas, signers, time")
+
+	rootCmd.PersistentFlags().StringVar(&EmailHost, "email-host", "", "SMTP server host")
+	rootCmd.PersistentFlags().IntVar(&EmailPort, "email-port", 587, "SMTP server port")
+	rootCmd.PersistentFlags().StringVar(&EmailUsername, "email-username", "", "SMTP username, no auth if empty")
+	rootCmd.PersistentFlags().StringVar(&EmailPassword, "email-password", "", "SMTP password")
+	rootCmd.PersistentFlags().StringVar(&EmailTLSMode, "email-tls", "starttls", "SMTP TLS mode: none, starttls or tls")
+	rootCmd.PersistentFlags().StringVar(&EmailFrom, "email-from", "", "Email address to send reports from")
+	rootCmd.PersistentFlags().StringSliceVar(&EmailTo, "email-to", []string{}, "Email addresses to send reports to")
+	rootCmd.PersistentFlags().StringVar(&EmailFailedTxsMode, "email-failed-txs", "report", "Whether to send failed txs by email: report, skip or only")
+	rootCmd.PersistentFlags().StringSliceVar(&EmailTxFields, "email-tx-fields", []string{"fee", "gas", "signers", "time"}, "Tx details to show in emails: fee, gas, signers, time")
+
+	rootCmd.PersistentFlags().StringVar(&AlertProviderName, "alert-provider", "pagerduty", "Incident management service to send alerts to: pagerduty or opsgenie")
+	rootCmd.PersistentFlags().StringVar(&AlertKey, "alert-key", "", "PagerDuty integration key or Opsgenie API key")
+	rootCmd.PersistentFlags().StringVar(&AlertURL, "alert-url", "", "Alerts API URL, if not the default one for the provider (like for Opsgenie EU)")
+	rootCmd.PersistentFlags().StringVar(&AlertFailedTxsMode, "alert-failed-txs", "report", "Whether to send alerts for failed txs: report, skip or only")
+
+	rootCmd.PersistentFlags().StringSliceVar(&WebhookURLs, "webhook-url", []string{}, "URLs to POST reports as JSON to")
+	rootCmd.PersistentFlags().StringVar(&WebhookSecret, "webhook-secret", "", "Secret to sign webhook requests body with, as HMAC-SHA256")
+	rootCmd.PersistentFlags().IntVar(&WebhookMaxRetries, "webhook-max-retries", 5, "How many times to retry sending a report to webhook if it fails")
+	rootCmd.PersistentFlags().StringVar(&WebhookFailedTxsMode, "webhook-failed-txs", "report", "Whether to send failed txs to webhooks: report, skip or only")
+
+	rootCmd.PersistentFlags().BoolVar(&StdoutEnabled, "stdout", false, "Print reports to stdout")
+	rootCmd.PersistentFlags().StringVar(&StdoutFormat, "stdout-format", "plain", "Format to print reports to stdout in: plain, html or markdown")
+	rootCmd.PersistentFlags().StringVar(&StdoutFailedTxsMode, "stdout-failed-txs", "report", "Whether to print failed txs to stdout: report, skip or only")
+	rootCmd.PersistentFlags().StringSliceVar(&StdoutTxFields, "stdout-tx-fields", []string{"fee", "gas", "signers", "time"}, "Tx details to print to stdout: fee, gas, signers, time")
+	rootCmd.PersistentFlags().BoolVar(&DryRun, "dry-run", false, "Only print reports to stdout instead of sending them to any other reporters")
 
 	rootCmd.PersistentFlags().StringVar(&MintscanProject, "mintscan-project", "cosmos", "mintscan.io/* project to generate links to")
 	rootCmd.PersistentFlags().StringVar(&CoingeckoCurrency, "coingecko-currency", "", "Coingecko currency name")
+	rootCmd.PersistentFlags().StringVar(&FiatCurrency, "fiat-currency", "usd", "Fiat currency to show the tokens value in, like usd or eur")
+	rootCmd.PersistentFlags().StringVar(&PricesFile, "prices-file", "", "Path to the JSON file with the tokens prices, for the tokens with the static price source")
 	rootCmd.PersistentFlags().StringVar(&NodeAddress, "node", "localhost:9090", "RPC node address")
 	rootCmd.PersistentFlags().StringVar(&TendermintRpcAddress, "tendermint-rpc", "tcp://localhost:26657", "Tendermint RPC node address")
+	rootCmd.PersistentFlags().BoolVar(&UsePolling, "polling", false, "Poll Tendermint RPC for new txs instead of subscribing via websocket")
+	rootCmd.PersistentFlags().DurationVar(&PollingInterval, "polling-interval", 10*time.Second, "How often to poll Tendermint RPC for new txs")
+
+	rootCmd.AddCommand(listMsgTypesCmd)
+	rootCmd.AddCommand(replayCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		log.Fatal().Err(err).Msg("Could not start application")
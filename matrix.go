@@ -0,0 +1,450 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const MatrixSyncTimeout = 30 * time.Second
+
+var htmlTagRegexp = regexp.MustCompile(`<[^>]*>`)
+
+type MatrixReporter struct {
+	ReporterName    string
+	ReporterQueries []string
+	ReporterFilter  *Filter
+
+	MatrixHomeserver  string
+	MatrixAccessToken string
+	MatrixRoom        string
+	MatrixAdmins      []string
+
+	MatrixSetAliasCommand    string
+	MatrixClearAliasCommand  string
+	MatrixListAliasesCommand string
+
+	MatrixFailedTxsMode FailedTxsMode
+	MatrixTxFields      TxFields
+
+	HttpClient      *http.Client
+	HtmlSerializer  Serializer
+	PlainSerializer Serializer
+
+	userID        string
+	roomID        string
+	lastTxnNumber uint64
+}
+
+// MatrixListener is the /sync loop of an account. The reporters with the same access token
+// share it, as otherwise each of them would answer every command, and the commands are
+// answered by the reporter of the room they're sent to.
+type MatrixListener struct {
+	reporters map[string]*MatrixReporter
+	mutex     sync.RWMutex
+}
+
+var (
+	matrixListeners      = make(map[string]*MatrixListener)
+	matrixListenersMutex sync.Mutex
+)
+
+type MatrixMessage struct {
+	MsgType       string              `json:"msgtype"`
+	Body          string              `json:"body"`
+	Format        string              `json:"format,omitempty"`
+	FormattedBody string              `json:"formatted_body,omitempty"`
+	RelatesTo     *MatrixMessageReply `json:"m.relates_to,omitempty"`
+}
+
+type MatrixMessageReply struct {
+	InReplyTo struct {
+		EventID string `json:"event_id"`
+	} `json:"m.in_reply_to"`
+}
+
+type MatrixEvent struct {
+	Type    string `json:"type"`
+	EventID string `json:"event_id"`
+	Sender  string `json:"sender"`
+	Content struct {
+		MsgType string `json:"msgtype"`
+		Body    string `json:"body"`
+	} `json:"content"`
+}
+
+type MatrixSyncResponse struct {
+	NextBatch string `json:"next_batch"`
+	Rooms     struct {
+		Join map[string]struct {
+			Timeline struct {
+				Events []MatrixEvent `json:"events"`
+			} `json:"timeline"`
+		} `json:"join"`
+	} `json:"rooms"`
+}
+
+func (r MatrixReporter) serializeWith(report Report, serializer Serializer) string {
+	var sb strings.Builder
+
+	serializer = serializer.withChain(report.Chain)
+
+	sb.WriteString(report.Tx.Serialize(serializer) + "\n\n")
+
+	for _, msg := range report.Msgs {
+		sb.WriteString(msg.Serialize(serializer) + "\n\n")
+	}
+
+	return sb.String()
+}
+
+func (r MatrixReporter) Serialize(report Report) string {
+	return r.serializeWith(report, r.HtmlSerializer)
+}
+
+func (r *MatrixReporter) Init() {
+	if r.MatrixHomeserver == "" || r.MatrixAccessToken == "" || r.MatrixRoom == "" {
+		log.Debug().Msg("Matrix credentials not set, not creating Matrix reporter.")
+		return
+	}
+
+	r.MatrixHomeserver = strings.TrimSuffix(r.MatrixHomeserver, "/")
+	r.HttpClient = &http.Client{Timeout: MatrixSyncTimeout + 10*time.Second}
+	r.HtmlSerializer = NewHtmlSerializer(r.MatrixTxFields)
+	r.PlainSerializer = NewPlainSerializer(r.MatrixTxFields)
+
+	var whoami struct {
+		UserID string `json:"user_id"`
+	}
+	if err := r.doRequest(http.MethodGet, "/account/whoami", nil, &whoami); err != nil {
+		log.Warn().Err(err).Msg("Could not log in to Matrix")
+		r.HttpClient = nil
+		return
+	}
+
+	// joining returns the room ID even if the room is set by its alias
+	var joined struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := r.doRequest(
+		http.MethodPost,
+		"/join/"+url.PathEscape(r.MatrixRoom),
+		struct{}{},
+		&joined,
+	); err != nil {
+		log.Warn().Err(err).Str("room", r.MatrixRoom).Msg("Could not join Matrix room")
+		r.HttpClient = nil
+		return
+	}
+
+	r.userID = whoami.UserID
+	r.roomID = joined.RoomID
+
+	log.Info().
+		Str("user", r.userID).
+		Str("room", r.roomID).
+		Msg("Logged in to Matrix")
+
+	matrixListenersMutex.Lock()
+	defer matrixListenersMutex.Unlock()
+
+	listenerKey := r.MatrixHomeserver + " " + r.MatrixAccessToken
+	if listener, found := matrixListeners[listenerKey]; found {
+		log.Debug().
+			Str("reporter", r.ReporterName).
+			Msg("Matrix account is already used by another reporter, sharing its sync loop")
+		listener.addReporter(r)
+		return
+	}
+
+	listener := &MatrixListener{reporters: make(map[string]*MatrixReporter)}
+	listener.addReporter(r)
+	matrixListeners[listenerKey] = listener
+
+	go r.listen(listener)
+}
+
+func (l *MatrixListener) addReporter(reporter *MatrixReporter) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.reporters[reporter.roomID] = reporter
+}
+
+func (l *MatrixListener) getReporter(roomID string) (*MatrixReporter, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	reporter, found := l.reporters[roomID]
+	return reporter, found
+}
+
+// listen long-polls the Matrix /sync endpoint for the messages in the rooms of all the reporters
+// sharing it and processes the alias commands in them. The messages sent before the bot
+// has started are skipped.
+func (r *MatrixReporter) listen(listener *MatrixListener) {
+	since := ""
+
+	for {
+		query := url.Values{}
+		query.Set("timeout", fmt.Sprintf("%d", MatrixSyncTimeout.Milliseconds()))
+		if since != "" {
+			query.Set("since", since)
+		} else {
+			query.Set("timeout", "0")
+		}
+
+		var response MatrixSyncResponse
+		if err := r.doRequest(http.MethodGet, "/sync?"+query.Encode(), nil, &response); err != nil {
+			log.Error().Err(err).Msg("Could not sync with Matrix, retrying")
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		if since != "" {
+			for roomID, room := range response.Rooms.Join {
+				reporter, found := listener.getReporter(roomID)
+				if !found {
+					continue
+				}
+
+				for _, event := range room.Timeline.Events {
+					reporter.processEvent(event)
+				}
+			}
+		}
+
+		since = response.NextBatch
+	}
+}
+
+func (r *MatrixReporter) processEvent(event MatrixEvent) {
+	if event.Type != "m.room.message" || event.Sender == r.userID || event.Content.MsgType != "m.text" {
+		return
+	}
+
+	commandAndText := strings.SplitN(strings.TrimSpace(event.Content.Body), " ", 2)
+	args := ""
+	if len(commandAndText) > 1 {
+		args = commandAndText[1]
+	}
+
+	command := commandAndText[0]
+	if command != r.MatrixSetAliasCommand && command != r.MatrixClearAliasCommand && command != r.MatrixListAliasesCommand {
+		return
+	}
+
+	// the bot only reads the reports room, but it can be public, so the commands
+	// can be limited to some of its members
+	if len(r.MatrixAdmins) > 0 && !contains(r.MatrixAdmins, event.Sender) {
+		log.Warn().
+			Str("command", command).
+			Str("user", event.Sender).
+			Msg("Received command from a user that is not allowed, skipping")
+		return
+	}
+
+	var text string
+
+	switch command {
+	case r.MatrixSetAliasCommand:
+		text = r.processSetAliasCommand(args)
+	case r.MatrixClearAliasCommand:
+		text = r.processClearAliasCommand(args)
+	case r.MatrixListAliasesCommand:
+		text = r.processListAliasesCommand()
+	default:
+		return
+	}
+
+	log.Info().
+		Str("command", commandAndText[0]).
+		Str("text", event.Content.Body).
+		Str("user", event.Sender).
+		Msg("Received command")
+
+	reply := &MatrixMessageReply{}
+	reply.InReplyTo.EventID = event.EventID
+
+	if err := r.sendMessage(getMatrixPlainText(text), text, reply); err != nil {
+		log.Error().Err(err).Str("command", commandAndText[0]).Msg("Could not send response to Matrix command")
+	}
+}
+
+func (r *MatrixReporter) processSetAliasCommand(args string) string {
+	chain, text, found := findChainForCommand(args)
+	params := strings.SplitN(text, " ", 2)
+
+	if !found || len(params) < 2 {
+		log.Info().Msg("set-alias: args length < 2 or chain not found")
+		return fmt.Sprintf(
+			"Usage: <code>%s</code> %s&lt;wallet-address&gt; &lt;alias&gt;",
+			r.MatrixSetAliasCommand,
+			getChainCommandUsage(),
+		)
+	}
+
+	chain.LabelsConfigManager.setWalletLabel(params[0], params[1])
+	return fmt.Sprintf(
+		"Successfully set alias for %s: %s",
+		r.HtmlSerializer.LinksSerializer(chain.makeMintscanAccountLink(params[0]), params[0]),
+		r.HtmlSerializer.CodeSerializer(params[1]),
+	)
+}
+
+func (r *MatrixReporter) processClearAliasCommand(args string) string {
+	chain, text, found := findChainForCommand(args)
+
+	if !found || text == "" {
+		log.Info().Msg("clear-alias: args length < 1 or chain not found")
+		return fmt.Sprintf(
+			"Usage: <code>%s</code> %s&lt;wallet-address&gt;",
+			r.MatrixClearAliasCommand,
+			getChainCommandUsage(),
+		)
+	}
+
+	address := strings.SplitN(text, " ", 2)[0]
+	chain.LabelsConfigManager.clearWalletLabel(address)
+	return fmt.Sprintf(
+		"Successfully cleared alias for %s",
+		r.HtmlSerializer.LinksSerializer(chain.makeMintscanAccountLink(address), address),
+	)
+}
+
+func (r *MatrixReporter) processListAliasesCommand() string {
+	var sb strings.Builder
+
+	for _, chain := range Chains {
+		sb.WriteString(r.HtmlSerializer.StrongSerializer(
+			fmt.Sprintf("Wallet aliases on %s:", chain.Name),
+		) + "\n")
+
+		if len(chain.LabelsConfigManager.config.WalletLabels) == 0 {
+			sb.WriteString(fmt.Sprintf(
+				"No label aliases are set. You can set one using <code>%s</code> %s&lt;wallet-address&gt; &lt;alias&gt;\n",
+				r.MatrixSetAliasCommand,
+				getChainCommandUsage(),
+			))
+		}
+
+		for key, value := range chain.LabelsConfigManager.config.WalletLabels {
+			sb.WriteString(fmt.Sprintf(
+				"• %s: %s\n",
+				r.HtmlSerializer.LinksSerializer(chain.makeMintscanAccountLink(key), key),
+				r.HtmlSerializer.CodeSerializer(value),
+			))
+		}
+	}
+
+	return sb.String()
+}
+
+// sendMessage sends a message with both the HTML body and a plain text fallback
+// for clients that do not support formatting.
+func (r *MatrixReporter) sendMessage(plainText string, htmlText string, reply *MatrixMessageReply) error {
+	message := MatrixMessage{
+		MsgType:       "m.text",
+		Body:          plainText,
+		Format:        "org.matrix.custom.html",
+		FormattedBody: getHtmlWithLineBreaks(htmlText),
+		RelatesTo:     reply,
+	}
+
+	txnID := fmt.Sprintf("%d-%d", time.Now().UnixNano(), atomic.AddUint64(&r.lastTxnNumber, 1))
+
+	return r.doRequest(
+		http.MethodPut,
+		fmt.Sprintf("/rooms/%s/send/m.room.message/%s", url.PathEscape(r.roomID), txnID),
+		message,
+		nil,
+	)
+}
+
+// getMatrixPlainText strips the tags from the command responses, which are only written
+// in HTML, to get the fallback body.
+func getMatrixPlainText(text string) string {
+	return html.UnescapeString(htmlTagRegexp.ReplaceAllString(text, ""))
+}
+
+func (r *MatrixReporter) doRequest(method string, path string, body interface{}, result interface{}) error {
+	var requestBody []byte
+	if body != nil {
+		bytesJSON, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		requestBody = bytesJSON
+	}
+
+	request, err := http.NewRequest(method, r.MatrixHomeserver+"/_matrix/client/v3"+path, bytes.NewReader(requestBody))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Authorization", "Bearer "+r.MatrixAccessToken)
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := r.HttpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("got unexpected status code %d: %s", response.StatusCode, string(responseBody))
+	}
+
+	if result == nil {
+		return nil
+	}
+
+	return json.Unmarshal(responseBody, result)
+}
+
+func (r MatrixReporter) Enabled() bool {
+	return r.HttpClient != nil
+}
+
+func (r MatrixReporter) Serializer() Serializer {
+	return r.HtmlSerializer
+}
+
+func (r *MatrixReporter) SendReport(report Report) error {
+	return r.sendMessage(
+		r.serializeWith(report, r.PlainSerializer),
+		r.serializeWith(report, r.HtmlSerializer),
+		nil,
+	)
+}
+
+func (r MatrixReporter) Name() string {
+	return r.ReporterName
+}
+
+func (r MatrixReporter) FailedTxsMode() FailedTxsMode {
+	return r.MatrixFailedTxsMode
+}
+
+func (r MatrixReporter) Queries() []string {
+	return r.ReporterQueries
+}
+
+func (r MatrixReporter) Filter() *Filter {
+	return r.ReporterFilter
+}
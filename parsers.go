@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	cosmosTypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/spf13/cobra"
+)
+
+// MsgParser converts a tx message into a Msg that can be sent to reporters.
+// It should return an empty Msg if the message could not be parsed.
+type MsgParser func(message *cosmosTypes.Any, chain *Chain, block int64) Msg
+
+var msgParsers = make(map[string]MsgParser)
+
+// RegisterMsgParser adds a parser for the messages with a given type URL.
+// Meant to be called from init() in the file where the parser is declared.
+func RegisterMsgParser(typeUrl string, parser MsgParser) {
+	if _, found := msgParsers[typeUrl]; found {
+		panic(fmt.Sprintf("Parser for %s is already registered", typeUrl))
+	}
+
+	msgParsers[typeUrl] = parser
+}
+
+func getMsgParser(typeUrl string) (MsgParser, bool) {
+	parser, found := msgParsers[typeUrl]
+	return parser, found
+}
+
+func getSupportedMsgTypes() []string {
+	typeUrls := make([]string, 0, len(msgParsers))
+	for typeUrl := range msgParsers {
+		typeUrls = append(typeUrls, typeUrl)
+	}
+
+	sort.Strings(typeUrls)
+	return typeUrls
+}
+
+var listMsgTypesCmd = &cobra.Command{
+	Use:   "list-msg-types",
+	Short: "List message types this build can parse",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, typeUrl := range getSupportedMsgTypes() {
+			fmt.Println(typeUrl)
+		}
+	},
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"time"
+)
+
+// poll is used instead of the websocket subscription for nodes that do not expose it.
+// It checks for new blocks on an interval and searches for the txs matching
+// the queries in the new blocks.
+func (c *Chain) poll() {
+	fromHeight := stateManager.getLastHeight(c.Name)
+
+	log.Info().
+		Str("chain", c.Name).
+		Dur("interval", PollingInterval).
+		Int64("last-height", fromHeight).
+		Msg("Polling for new transactions")
+
+	for {
+		latestHeight, err := c.getLatestHeight()
+		if err != nil {
+			log.Error().Err(err).Str("chain", c.Name).Msg("Could not get latest height")
+			time.Sleep(PollingInterval)
+			continue
+		}
+
+		// not processing the whole chain history on the first run
+		if fromHeight == 0 {
+			fromHeight = latestHeight
+		}
+
+		fromHeight = c.getBackfillStartHeight(fromHeight, latestHeight)
+
+		if latestHeight >= fromHeight {
+			if err := c.processTxsInRange(fromHeight, latestHeight); err != nil {
+				log.Error().Err(err).Str("chain", c.Name).Msg("Could not process new txs")
+				time.Sleep(PollingInterval)
+				continue
+			}
+
+			stateManager.setLastHeight(c.Name, latestHeight)
+			fromHeight = latestHeight + 1
+		}
+
+		time.Sleep(PollingInterval)
+	}
+}
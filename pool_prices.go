@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// DefaultQuoteExponent is the exponent of the quote token if it's not set,
+// as most of the stablecoins have 6 decimals.
+const DefaultQuoteExponent = 6
+
+type poolPrice struct {
+	price      float64
+	lastUpdate time.Time
+}
+
+// PoolPriceProvider calculates the token price out of a DEX pool reserves, which are
+// the pool account balances. This is the spot price of the pools with equal weights,
+// like the 50/50 Osmosis ones. The quote token is either priced by another price source
+// on this chain, or is a stablecoin worth 1 in fiat.
+type PoolPriceProvider struct {
+	chain        *Chain
+	priceManager *PriceManager
+	sources      map[string]PriceSourceConfig
+	grpcWrappers map[string]*GrpcWrapper
+	prices       map[string]poolPrice
+	mutex        sync.Mutex
+}
+
+func NewPoolPriceProvider(chain *Chain, priceManager *PriceManager) *PoolPriceProvider {
+	return &PoolPriceProvider{
+		chain:        chain,
+		priceManager: priceManager,
+		sources:      make(map[string]PriceSourceConfig),
+		grpcWrappers: make(map[string]*GrpcWrapper),
+		prices:       make(map[string]poolPrice),
+	}
+}
+
+func (p *PoolPriceProvider) Name() string {
+	return "pool"
+}
+
+// addDenom adds the pool to take the token price from. The pool can be on another chain,
+// then its node should be set, and the denoms are the ones on that chain.
+func (p *PoolPriceProvider) addDenom(denom string, source PriceSourceConfig) {
+	if source.QuoteExponent == 0 {
+		source.QuoteExponent = DefaultQuoteExponent
+	}
+
+	if source.PoolNode != "" {
+		if _, found := p.grpcWrappers[source.PoolNode]; !found {
+			p.grpcWrappers[source.PoolNode] = InitGrpcWrapper(source.PoolNode)
+		}
+	}
+
+	p.sources[denom] = source
+}
+
+func (p *PoolPriceProvider) getGrpcWrapper(source PriceSourceConfig) *GrpcWrapper {
+	if source.PoolNode == "" {
+		return p.chain.GrpcWrapper
+	}
+
+	return p.grpcWrappers[source.PoolNode]
+}
+
+func (p *PoolPriceProvider) GetPrice(denom string) (float64, error) {
+	source, found := p.sources[getPriceKey(denom)]
+	if !found {
+		return 0, fmt.Errorf("no pool for denom %s", denom)
+	}
+
+	// the denom from the config is lowercased, so the actual one is used
+	if source.PoolDenom == "" {
+		source.PoolDenom = denom
+	}
+
+	p.mutex.Lock()
+	cached, found := p.prices[denom]
+	p.mutex.Unlock()
+
+	if found && time.Since(cached.lastUpdate) < PriceCacheDuration {
+		log.Trace().Str("denom", denom).Msg("Using pool price from cache.")
+		return cached.price, nil
+	}
+
+	quotePrice := 1.0
+	quoteCoefficient := sdk.NewDecFromInt(sdk.NewIntWithDecimal(1, source.QuoteExponent))
+
+	if source.QuotePriceDenom != "" {
+		price, found := p.priceManager.getPrice(source.QuotePriceDenom)
+		if !found {
+			return 0, fmt.Errorf("no price for quote denom %s", source.QuotePriceDenom)
+		}
+
+		quotePrice = price
+		quoteCoefficient = p.chain.getDenomInfo(source.QuotePriceDenom).Coefficient
+	}
+
+	grpcWrapper := p.getGrpcWrapper(source)
+
+	log.Debug().
+		Str("chain", p.chain.Name).
+		Str("denom", denom).
+		Str("pool", source.PoolAddress).
+		Msg("Fetching pool reserves")
+
+	tokenReserve, err := grpcWrapper.getBalance(source.PoolAddress, source.PoolDenom)
+	if err != nil {
+		return 0, err
+	}
+
+	quoteReserve, err := grpcWrapper.getBalance(source.PoolAddress, source.QuoteDenom)
+	if err != nil {
+		return 0, err
+	}
+
+	if !tokenReserve.IsPositive() {
+		return 0, fmt.Errorf("pool %s has no %s", source.PoolAddress, source.PoolDenom)
+	}
+
+	tokenAmount := tokenReserve.ToDec().Quo(p.chain.getDenomInfo(denom).Coefficient)
+	quoteAmount := quoteReserve.ToDec().Quo(quoteCoefficient)
+	price := getFiatValue(quoteAmount.Quo(tokenAmount), quotePrice).MustFloat64()
+
+	p.mutex.Lock()
+	p.prices[denom] = poolPrice{price: price, lastUpdate: time.Now()}
+	p.mutex.Unlock()
+
+	return price, nil
+}
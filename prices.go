@@ -0,0 +1,163 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// PriceCacheDuration is how long the fetched prices are used before fetching them again.
+const PriceCacheDuration = 10 * time.Minute
+
+// PriceProvider returns the fiat price of one display unit of a token, by its base denom.
+type PriceProvider interface {
+	Name() string
+	GetPrice(denom string) (float64, error)
+}
+
+// PriceSourceConfig is where to take the token price from, set per base denom.
+// Only the fields of the selected provider are used.
+type PriceSourceConfig struct {
+	Provider        string `mapstructure:"provider"`
+	CoingeckoID     string `mapstructure:"coingecko-id"`
+	PoolNode        string `mapstructure:"pool-node"`
+	PoolAddress     string `mapstructure:"pool-address"`
+	PoolDenom       string `mapstructure:"pool-denom"`
+	QuoteDenom      string `mapstructure:"quote-denom"`
+	QuoteExponent   int    `mapstructure:"quote-exponent"`
+	QuotePriceDenom string `mapstructure:"quote-price-denom"`
+}
+
+// PriceManager knows which provider to ask for the price of each token.
+type PriceManager struct {
+	chain     string
+	providers map[string]PriceProvider
+}
+
+func NewPriceManager(chain *Chain) *PriceManager {
+	manager := &PriceManager{
+		chain:     chain.Name,
+		providers: make(map[string]PriceProvider),
+	}
+
+	sources := make(map[string]PriceSourceConfig, len(chain.Prices))
+	for denom, source := range chain.Prices {
+		sources[getPriceKey(denom)] = source
+	}
+
+	// --coingecko-currency is the Coingecko ID of the native token, kept for the old configs
+	if _, found := sources[getPriceKey(chain.BaseDenom)]; !found && chain.CoingeckoCurrency != "" && chain.BaseDenom != "" {
+		sources[getPriceKey(chain.BaseDenom)] = PriceSourceConfig{
+			Provider:    "coingecko",
+			CoingeckoID: chain.CoingeckoCurrency,
+		}
+	}
+
+	var (
+		coingecko *CoingeckoPriceProvider
+		static    *StaticPriceProvider
+		pool      *PoolPriceProvider
+	)
+
+	for denom, source := range sources {
+		switch source.Provider {
+		case "coingecko":
+			if source.CoingeckoID == "" {
+				log.Fatal().Str("chain", chain.Name).Str("denom", denom).Msg("Coingecko ID is not set for the price source")
+			}
+
+			if coingecko == nil {
+				coingecko = NewCoingeckoPriceProvider(chain.FiatCurrency)
+			}
+
+			coingecko.addDenom(denom, source.CoingeckoID)
+			manager.providers[denom] = coingecko
+		case "static":
+			if chain.PricesFile == "" {
+				log.Fatal().Str("chain", chain.Name).Str("denom", denom).Msg("Prices file is not set for the static price source")
+			}
+
+			if static == nil {
+				static = NewStaticPriceProvider(chain.PricesFile)
+			}
+
+			manager.providers[denom] = static
+		case "pool":
+			if source.PoolAddress == "" || source.QuoteDenom == "" {
+				log.Fatal().Str("chain", chain.Name).Str("denom", denom).Msg("Pool address and quote denom should be set for the pool price source")
+			}
+
+			if pool == nil {
+				pool = NewPoolPriceProvider(chain, manager)
+			}
+
+			pool.addDenom(denom, source)
+			manager.providers[denom] = pool
+		default:
+			log.Fatal().
+				Str("chain", chain.Name).
+				Str("denom", denom).
+				Str("provider", source.Provider).
+				Msg("Unsupported price provider, expected coingecko, static or pool")
+		}
+	}
+
+	checkQuotePriceLoops(chain.Name, sources)
+
+	log.Debug().Str("chain", chain.Name).Int("count", len(manager.providers)).Msg("Loaded price sources")
+
+	return manager
+}
+
+// checkQuotePriceLoops makes sure the pool prices do not depend on each other in a loop,
+// as that would never return.
+func checkQuotePriceLoops(chain string, sources map[string]PriceSourceConfig) {
+	for denom := range sources {
+		visited := map[string]bool{denom: true}
+		current := denom
+
+		for {
+			source, found := sources[current]
+			if !found || source.Provider != "pool" || source.QuotePriceDenom == "" {
+				break
+			}
+
+			current = getPriceKey(source.QuotePriceDenom)
+			if visited[current] {
+				log.Fatal().Str("chain", chain).Str("denom", denom).Msg("Pool price sources depend on each other in a loop")
+			}
+
+			visited[current] = true
+		}
+	}
+}
+
+// getPriceKey returns the key the price sources are stored by. viper lowercases the config keys,
+// so the IBC denoms hashes would never match otherwise.
+func getPriceKey(denom string) string {
+	return strings.ToLower(denom)
+}
+
+// getPrice returns the fiat price of one display unit of the token, if there's a source for it.
+func (m *PriceManager) getPrice(denom string) (float64, bool) {
+	provider, found := m.providers[getPriceKey(denom)]
+	if !found {
+		return 0, false
+	}
+
+	price, err := provider.GetPrice(denom)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("chain", m.chain).
+			Str("denom", denom).
+			Str("provider", provider.Name()).
+			Msg("Could not get price")
+		return 0, false
+	}
+
+	if price == 0 {
+		return 0, false
+	}
+
+	return price, true
+}
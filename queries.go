@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+	tmquery "github.com/tendermint/tendermint/libs/pubsub/query"
+)
+
+// NamedQuery is a query the chain is subscribed to. The queries set with "query"
+// have no name, so they are not shown in notifications and cannot be routed by.
+type NamedQuery struct {
+	Name    string
+	Query   string
+	Runtime bool
+	parsed  *tmquery.Query
+}
+
+// RuntimeQuery is a query added with a chat command, persisted in the state database.
+type RuntimeQuery struct {
+	Name  string `json:"name"`
+	Query string `json:"query"`
+}
+
+// getChainQueries returns the named queries sorted by name, followed by the unnamed ones
+// and the ones for the watched addresses.
+func getChainQueries(config ChainConfig) []NamedQuery {
+	queries := []NamedQuery{}
+
+	names := make([]string, 0, len(config.NamedQueries))
+	for name := range config.NamedQueries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		queries = append(queries, newNamedQuery(config.Name, name, config.NamedQueries[name]))
+	}
+
+	for _, query := range config.Queries {
+		queries = append(queries, newNamedQuery(config.Name, "", query))
+	}
+
+	return append(queries, getWatchQueries(config.Name, config.Watch)...)
+}
+
+func newNamedQuery(chain string, name string, query string) NamedQuery {
+	parsed, err := tmquery.New(query)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("chain", chain).
+			Str("name", name).
+			Str("query", query).
+			Msg("Could not parse query")
+	}
+
+	return NamedQuery{
+		Name:   name,
+		Query:  query,
+		parsed: parsed,
+	}
+}
+
+// getRuntimeQueries returns the queries added with chat commands before the restart.
+func getRuntimeQueries(chain string) []NamedQuery {
+	queries := []NamedQuery{}
+
+	for _, query := range stateManager.getQueries(chain) {
+		parsed, err := tmquery.New(query.Query)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("chain", chain).
+				Str("name", query.Name).
+				Str("query", query.Query).
+				Msg("Could not parse saved query, skipping")
+			continue
+		}
+
+		queries = append(queries, NamedQuery{
+			Name:    query.Name,
+			Query:   query.Query,
+			Runtime: true,
+			parsed:  parsed,
+		})
+	}
+
+	return queries
+}
+
+// getTxEvents converts the tx events to the format the queries are matched against,
+// the same one as the websocket subscription uses.
+func getTxEvents(txResult abciTypes.TxResult, hash string) map[string][]string {
+	events := map[string][]string{
+		"tm.event":  {"Tx"},
+		"tx.hash":   {hash},
+		"tx.height": {fmt.Sprintf("%d", txResult.Height)},
+	}
+
+	for _, event := range txResult.Result.Events {
+		for _, attribute := range event.Attributes {
+			key := event.Type + "." + string(attribute.Key)
+			events[key] = append(events[key], string(attribute.Value))
+		}
+	}
+
+	return events
+}
+
+// getMatchedQueries returns the names of the named queries the tx matches.
+// The tx matches the query it was received by, but it can match some other ones as well,
+// and only the first one is received as the rest are skipped as already sent.
+func (c *Chain) getMatchedQueries(events map[string][]string) []string {
+	matched := []string{}
+
+	for _, query := range c.getQueries() {
+		if query.Name == "" {
+			continue
+		}
+
+		matches, err := query.parsed.Matches(events)
+		if err != nil {
+			log.Warn().Err(err).Str("chain", c.Name).Str("query", query.Name).Msg("Could not match query")
+			continue
+		}
+
+		// the watched addresses have several queries with the same name
+		if matches && !contains(matched, query.Name) {
+			matched = append(matched, query.Name)
+		}
+	}
+
+	return matched
+}
+
+// matchesAnyQuery returns whether the tx matches at least one of the queries. It's used
+// when subscribed to all txs, because there are more queries than the node allows.
+func (c *Chain) matchesAnyQuery(events map[string][]string) bool {
+	for _, query := range c.getQueries() {
+		if matches, err := query.parsed.Matches(events); err == nil && matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shouldReceive returns whether the reporter should receive a tx that matched
+// the given queries. Reporters without queries set receive all txs.
+func shouldReceive(reporter Reporter, matchedQueries []string) bool {
+	if len(reporter.Queries()) == 0 {
+		return true
+	}
+
+	for _, query := range matchedQueries {
+		if contains(reporter.Queries(), query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Chain) getQueries() []NamedQuery {
+	c.queriesMutex.RLock()
+	defer c.queriesMutex.RUnlock()
+
+	return c.queries
+}
+
+// getSubscriptions returns the queries the chain is subscribed to
+// and whether the txs should be matched against the queries locally.
+func (c *Chain) getSubscriptions() ([]string, bool) {
+	c.queriesMutex.RLock()
+	defer c.queriesMutex.RUnlock()
+
+	return c.subscriptions, c.matchLocally
+}
+
+// addQuery validates the query, subscribes to it and saves it, so it's used after restart.
+func (c *Chain) addQuery(name string, query string) error {
+	parsed, err := tmquery.New(query)
+	if err != nil {
+		return fmt.Errorf("invalid query: %s", strings.Join(strings.Fields(err.Error()), " "))
+	}
+
+	c.queriesMutex.Lock()
+	defer c.queriesMutex.Unlock()
+
+	for _, existing := range c.queries {
+		if existing.Name == name {
+			return fmt.Errorf("query %s already exists", name)
+		}
+	}
+
+	queries := make([]NamedQuery, len(c.queries), len(c.queries)+1)
+	copy(queries, c.queries)
+	queries = append(queries, NamedQuery{
+		Name:    name,
+		Query:   query,
+		Runtime: true,
+		parsed:  parsed,
+	})
+
+	return c.setQueries(queries)
+}
+
+// removeQuery unsubscribes from the query added with a command. The ones set
+// in the config cannot be removed, as they would come back after restart.
+func (c *Chain) removeQuery(name string) error {
+	c.queriesMutex.Lock()
+	defer c.queriesMutex.Unlock()
+
+	queries := []NamedQuery{}
+	found := false
+
+	for _, query := range c.queries {
+		if query.Name != name {
+			queries = append(queries, query)
+			continue
+		}
+
+		if !query.Runtime {
+			return fmt.Errorf("query %s is set in the config, remove it from there", name)
+		}
+
+		found = true
+	}
+
+	if !found {
+		return fmt.Errorf("query %s is not found", name)
+	}
+
+	return c.setQueries(queries)
+}
+
+// setQueries updates the subscriptions to match the new queries and saves the ones
+// added with commands. Should be called with the queries mutex locked.
+func (c *Chain) setQueries(queries []NamedQuery) error {
+	subscriptions, matchLocally := getSubscriptions(c.Name, queries, c.MaxSubscriptions)
+
+	if c.Client != nil && c.Client.IsRunning() {
+		subscribed := []string{}
+
+		for _, query := range subscriptions {
+			if contains(c.subscriptions, query) {
+				continue
+			}
+
+			if err := c.Client.Subscribe(context.Background(), query); err != nil {
+				for _, subscribedQuery := range subscribed {
+					c.unsubscribe(subscribedQuery)
+				}
+
+				return fmt.Errorf("could not subscribe: %s", err)
+			}
+
+			log.Info().Str("chain", c.Name).Str("query", query).Msg("Subscribed to query")
+			subscribed = append(subscribed, query)
+		}
+
+		for _, query := range c.subscriptions {
+			if !contains(subscriptions, query) {
+				c.unsubscribe(query)
+			}
+		}
+	}
+
+	runtimeQueries := []RuntimeQuery{}
+	for _, query := range queries {
+		if query.Runtime {
+			runtimeQueries = append(runtimeQueries, RuntimeQuery{Name: query.Name, Query: query.Query})
+		}
+	}
+
+	if err := stateManager.setQueries(c.Name, runtimeQueries); err != nil {
+		log.Error().Err(err).Str("chain", c.Name).Msg("Could not save queries")
+	}
+
+	c.queries = queries
+	c.subscriptions = subscriptions
+	c.matchLocally = matchLocally
+	return nil
+}
+
+func (c *Chain) unsubscribe(query string) {
+	if err := c.Client.Unsubscribe(context.Background(), query); err != nil {
+		log.Warn().Err(err).Str("chain", c.Name).Str("query", query).Msg("Could not unsubscribe from query")
+		return
+	}
+
+	log.Info().Str("chain", c.Name).Str("query", query).Msg("Unsubscribed from query")
+}
+
+// escapeQuery escapes the characters the queries often have, like < and >,
+// but which have a special meaning in the chats formatting. The code blocks are
+// escaped by the serializers, so it's only needed for the text outside of them.
+func escapeQuery(query string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(query)
+}
+
+// processListQueriesCommand returns the response to the command listing the queries of all chains.
+func processListQueriesCommand(serializer Serializer) string {
+	var sb strings.Builder
+
+	for _, chain := range Chains {
+		sb.WriteString(serializer.StrongSerializer(fmt.Sprintf("Queries on %s:", chain.Name)) + "\n")
+
+		for _, query := range chain.getQueries() {
+			name := query.Name
+			if name == "" {
+				name = "unnamed"
+			}
+
+			source := "config"
+			if query.Runtime {
+				source = "added with command"
+			}
+
+			sb.WriteString(fmt.Sprintf(
+				"• %s: %s (%s)\n",
+				name,
+				serializer.CodeSerializer(query.Query),
+				source,
+			))
+		}
+
+		if _, matchLocally := chain.getSubscriptions(); matchLocally {
+			sb.WriteString("There are more queries than the node allows to subscribe to, so all txs are matched locally.\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// processAddQueryCommand adds the query from the command text and returns the response.
+func processAddQueryCommand(serializer Serializer, command string, text string) string {
+	usage := fmt.Sprintf(
+		"Usage: %s %s&lt;name&gt; &lt;query&gt;",
+		serializer.CodeSerializer(command),
+		getChainCommandUsage(),
+	)
+
+	chain, argsText, found := findChainForCommand(text)
+	args := strings.SplitN(argsText, " ", 2)
+
+	if !found || len(args) < 2 {
+		log.Info().Str("command", command).Msg("Args length < 2 or chain not found")
+		return usage
+	}
+
+	name, query := strings.ToLower(args[0]), strings.TrimSpace(args[1])
+
+	if err := chain.addQuery(name, query); err != nil {
+		log.Warn().Err(err).Str("chain", chain.Name).Str("query", query).Msg("Could not add query")
+		return fmt.Sprintf("Could not add query: %s", escapeQuery(err.Error()))
+	}
+
+	response := fmt.Sprintf(
+		"Successfully added query %s on %s: %s",
+		serializer.CodeSerializer(name),
+		chain.Name,
+		serializer.CodeSerializer(query),
+	)
+
+	if !stateManager.isPersistent() {
+		response += "\n--state-path is not set, so the query will be gone after restart."
+	}
+
+	return response
+}
+
+// processRemoveQueryCommand removes the query from the command text and returns the response.
+func processRemoveQueryCommand(serializer Serializer, command string, text string) string {
+	usage := fmt.Sprintf(
+		"Usage: %s %s&lt;name&gt;",
+		serializer.CodeSerializer(command),
+		getChainCommandUsage(),
+	)
+
+	chain, name, found := findChainForCommand(text)
+	if !found || name == "" {
+		log.Info().Str("command", command).Msg("No name provided or chain not found")
+		return usage
+	}
+
+	name = strings.ToLower(name)
+
+	if err := chain.removeQuery(name); err != nil {
+		log.Warn().Err(err).Str("chain", chain.Name).Str("name", name).Msg("Could not remove query")
+		return fmt.Sprintf("Could not remove query: %s", escapeQuery(err.Error()))
+	}
+
+	return fmt.Sprintf("Successfully removed query %s on %s", serializer.CodeSerializer(name), chain.Name)
+}
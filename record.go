@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"github.com/spf13/cobra"
+	jsonRpcTypes "github.com/tendermint/tendermint/rpc/jsonrpc/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RecordedResponse is a single line of the file written in record mode. It's either
+// a websocket response or a gRPC lookup done on start or while processing the responses.
+type RecordedResponse struct {
+	Chain    string                    `json:"chain,omitempty"`
+	Response *jsonRpcTypes.RPCResponse `json:"response,omitempty"`
+	GrpcCall *RecordedGrpcCall         `json:"grpc_call,omitempty"`
+}
+
+// RecordedGrpcCall is a gRPC lookup, like denoms metadata or a validator, with the request
+// and the response serialized as protobuf, so the replay does not need the node.
+type RecordedGrpcCall struct {
+	Node     string `json:"node"`
+	Method   string `json:"method"`
+	Height   string `json:"height,omitempty"`
+	Request  []byte `json:"request"`
+	Response []byte `json:"response,omitempty"`
+	Code     uint32 `json:"code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// grpcMessage is what all the generated protobuf types implement.
+type grpcMessage interface {
+	Marshal() ([]byte, error)
+	Unmarshal([]byte) error
+}
+
+type ResponseRecorder struct {
+	file  *os.File
+	mutex sync.Mutex
+}
+
+func NewResponseRecorder(path string) *ResponseRecorder {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Fatal().Err(err).Str("path", path).Msg("Could not open record file")
+	}
+
+	log.Info().Str("path", path).Msg("Recording websocket responses and gRPC calls")
+
+	return &ResponseRecorder{file: file}
+}
+
+func (r *ResponseRecorder) Record(chain string, response jsonRpcTypes.RPCResponse) {
+	r.write(RecordedResponse{
+		Chain:    chain,
+		Response: &response,
+	})
+}
+
+func (r *ResponseRecorder) RecordGrpcCall(call RecordedGrpcCall) {
+	r.write(RecordedResponse{GrpcCall: &call})
+}
+
+// getGrpcInterceptor records all the gRPC calls to the node, both the successful and failed ones,
+// as the errors like a missing denom are handled too.
+func (r *ResponseRecorder) getGrpcInterceptor(node string) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		request interface{},
+		reply interface{},
+		conn *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		options ...grpc.CallOption,
+	) error {
+		err := invoker(ctx, method, request, reply, conn, options...)
+
+		call := RecordedGrpcCall{
+			Node:   node,
+			Method: method,
+			Height: getGrpcCallHeight(ctx),
+		}
+
+		requestBytes, marshalErr := marshalGrpcMessage(request)
+		if marshalErr != nil {
+			log.Error().Err(marshalErr).Str("method", method).Msg("Could not serialize gRPC request to record")
+			return err
+		}
+
+		call.Request = requestBytes
+
+		if err != nil {
+			call.Code = uint32(status.Code(err))
+			call.Error = status.Convert(err).Message()
+		} else if call.Response, marshalErr = marshalGrpcMessage(reply); marshalErr != nil {
+			log.Error().Err(marshalErr).Str("method", method).Msg("Could not serialize gRPC response to record")
+			return err
+		}
+
+		r.RecordGrpcCall(call)
+		return err
+	}
+}
+
+func (r *ResponseRecorder) write(recorded RecordedResponse) {
+	bytes, err := json.Marshal(recorded)
+	if err != nil {
+		log.Error().Err(err).Msg("Could not serialize response to record")
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, err := r.file.Write(append(bytes, '\n')); err != nil {
+		log.Error().Err(err).Msg("Could not write recorded response")
+	}
+}
+
+func (r *ResponseRecorder) Close() {
+	if err := r.file.Close(); err != nil {
+		log.Error().Err(err).Msg("Could not close record file")
+	}
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <file>",
+	Short: "Replay the websocket responses written with --record",
+	Args:  cobra.ExactArgs(1),
+	Run:   Replay,
+}
+
+// GrpcReplayer answers the gRPC lookups with the recorded responses, so the replay
+// works without the node and shows the same as it was when recording.
+type GrpcReplayer struct {
+	calls map[string]RecordedGrpcCall
+}
+
+// NewGrpcReplayer reads all the gRPC calls from the recorded file. If the same call was made
+// several times, the last response is used.
+func NewGrpcReplayer(path string) *GrpcReplayer {
+	replayer := &GrpcReplayer{calls: make(map[string]RecordedGrpcCall)}
+
+	readRecordedFile(path, func(recorded RecordedResponse) {
+		if recorded.GrpcCall == nil {
+			return
+		}
+
+		call := recorded.GrpcCall
+		replayer.calls[getGrpcCallKey(call.Node, call.Method, call.Height, call.Request)] = *call
+	})
+
+	log.Info().Int("count", len(replayer.calls)).Msg("Loaded recorded gRPC calls")
+
+	return replayer
+}
+
+func (p *GrpcReplayer) getGrpcInterceptor(node string) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		request interface{},
+		reply interface{},
+		conn *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		options ...grpc.CallOption,
+	) error {
+		requestBytes, err := marshalGrpcMessage(request)
+		if err != nil {
+			return err
+		}
+
+		call, found := p.calls[getGrpcCallKey(node, method, getGrpcCallHeight(ctx), requestBytes)]
+		if !found {
+			return status.Errorf(codes.Unavailable, "%s was not recorded", method)
+		}
+
+		if call.Code != uint32(codes.OK) {
+			return status.Error(codes.Code(call.Code), call.Error)
+		}
+
+		message, ok := reply.(grpcMessage)
+		if !ok {
+			return fmt.Errorf("cannot deserialize %T", reply)
+		}
+
+		return message.Unmarshal(call.Response)
+	}
+}
+
+func getGrpcCallKey(node string, method string, height string, request []byte) string {
+	return strings.Join([]string{node, method, height, hex.EncodeToString(request)}, " ")
+}
+
+// getGrpcCallHeight returns the height the call is done at, if it's not the latest one.
+func getGrpcCallHeight(ctx context.Context) string {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok {
+		if values := md.Get(grpctypes.GRPCBlockHeightHeader); len(values) > 0 {
+			return values[0]
+		}
+	}
+
+	return ""
+}
+
+func marshalGrpcMessage(message interface{}) ([]byte, error) {
+	protoMessage, ok := message.(grpcMessage)
+	if !ok {
+		return nil, fmt.Errorf("cannot serialize %T", message)
+	}
+
+	return protoMessage.Marshal()
+}
+
+func Replay(cmd *cobra.Command, args []string) {
+	setLogLevel()
+
+	// not using the persistent state, so the replayed txs are not skipped as already sent
+	stateManager = NewStateManager("", SentTxsTTL, SentTxsMaxCount)
+
+	// the lookups are answered from the file, so the chains should be created after it's read
+	grpcReplayer = NewGrpcReplayer(args[0])
+
+	initChains()
+	defer stopChains()
+
+	initReporters()
+
+	readRecordedFile(args[0], func(recorded RecordedResponse) {
+		if recorded.Response == nil {
+			return
+		}
+
+		chain := findChainByName(recorded.Chain)
+		if chain == nil {
+			log.Warn().Str("chain", recorded.Chain).Msg("Chain is not configured, skipping response")
+			return
+		}
+
+		chain.processResponse(*recorded.Response)
+	})
+}
+
+// readRecordedFile calls the callback for each line of the file written in record mode.
+func readRecordedFile(path string, callback func(RecordedResponse)) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal().Err(err).Str("path", path).Msg("Could not open file to replay")
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+
+	for scanner.Scan() {
+		var recorded RecordedResponse
+		if err := json.Unmarshal(scanner.Bytes(), &recorded); err != nil {
+			log.Error().Err(err).Msg("Could not parse recorded response")
+			continue
+		}
+
+		callback(recorded)
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Fatal().Err(err).Msg("Could not read file to replay")
+	}
+}
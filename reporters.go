@@ -0,0 +1,395 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+)
+
+var reporterTypes = []string{
+	"telegram",
+	"slack",
+	"discord",
+	"matrix",
+	"email",
+	"alert",
+	"webhook",
+	"stdout",
+}
+
+// ReporterConfig is a single reporter definition from the config file. The keys are
+// the same as the corresponding flags, and the ones that are not set default to the flags values.
+type ReporterConfig struct {
+	Name    string   `mapstructure:"name"`
+	Type    string   `mapstructure:"type"`
+	Queries []string `mapstructure:"queries"`
+	Filter  string   `mapstructure:"filter"`
+
+	TelegramToken              string   `mapstructure:"telegram-token"`
+	TelegramChat               int      `mapstructure:"telegram-chat"`
+	TelegramAdmins             []int    `mapstructure:"telegram-admins"`
+	TelegramSetAliasCommand    string   `mapstructure:"telegram-set-alias-command"`
+	TelegramClearAliasCommand  string   `mapstructure:"telegram-clear-alias-command"`
+	TelegramListAliasesCommand string   `mapstructure:"telegram-list-aliases-command"`
+	TelegramListQueriesCommand string   `mapstructure:"telegram-list-queries-command"`
+	TelegramAddQueryCommand    string   `mapstructure:"telegram-add-query-command"`
+	TelegramRemoveQueryCommand string   `mapstructure:"telegram-remove-query-command"`
+	TelegramFailedTxsMode      string   `mapstructure:"telegram-failed-txs"`
+	TelegramTxFields           []string `mapstructure:"telegram-tx-fields"`
+
+	SlackToken              string   `mapstructure:"slack-token"`
+	SlackChat               string   `mapstructure:"slack-chat"`
+	SlackSigningSecret      string   `mapstructure:"slack-signing-secret"`
+	SlackListenAddress      string   `mapstructure:"slack-listen-address"`
+	SlackAdmins             []string `mapstructure:"slack-admins"`
+	SlackSetAliasCommand    string   `mapstructure:"slack-set-alias-command"`
+	SlackClearAliasCommand  string   `mapstructure:"slack-clear-alias-command"`
+	SlackListAliasesCommand string   `mapstructure:"slack-list-aliases-command"`
+	SlackListQueriesCommand string   `mapstructure:"slack-list-queries-command"`
+	SlackAddQueryCommand    string   `mapstructure:"slack-add-query-command"`
+	SlackRemoveQueryCommand string   `mapstructure:"slack-remove-query-command"`
+	SlackFailedTxsMode      string   `mapstructure:"slack-failed-txs"`
+	SlackTxFields           []string `mapstructure:"slack-tx-fields"`
+
+	DiscordToken              string   `mapstructure:"discord-token"`
+	DiscordChannel            string   `mapstructure:"discord-channel"`
+	DiscordWebhookURL         string   `mapstructure:"discord-webhook-url"`
+	DiscordGuild              string   `mapstructure:"discord-guild"`
+	DiscordAdmins             []string `mapstructure:"discord-admins"`
+	DiscordSetAliasCommand    string   `mapstructure:"discord-set-alias-command"`
+	DiscordClearAliasCommand  string   `mapstructure:"discord-clear-alias-command"`
+	DiscordListAliasesCommand string   `mapstructure:"discord-list-aliases-command"`
+	DiscordFailedTxsMode      string   `mapstructure:"discord-failed-txs"`
+	DiscordTxFields           []string `mapstructure:"discord-tx-fields"`
+
+	MatrixHomeserver         string   `mapstructure:"matrix-homeserver"`
+	MatrixAccessToken        string   `mapstructure:"matrix-access-token"`
+	MatrixRoom               string   `mapstructure:"matrix-room"`
+	MatrixAdmins             []string `mapstructure:"matrix-admins"`
+	MatrixSetAliasCommand    string   `mapstructure:"matrix-set-alias-command"`
+	MatrixClearAliasCommand  string   `mapstructure:"matrix-clear-alias-command"`
+	MatrixListAliasesCommand string   `mapstructure:"matrix-list-aliases-command"`
+	MatrixFailedTxsMode      string   `mapstructure:"matrix-failed-txs"`
+	MatrixTxFields           []string `mapstructure:"matrix-tx-fields"`
+
+	EmailHost          string   `mapstructure:"email-host"`
+	EmailPort          int      `mapstructure:"email-port"`
+	EmailUsername      string   `mapstructure:"email-username"`
+	EmailPassword      string   `mapstructure:"email-password"`
+	EmailTLSMode       string   `mapstructure:"email-tls"`
+	EmailFrom          string   `mapstructure:"email-from"`
+	EmailTo            []string `mapstructure:"email-to"`
+	EmailFailedTxsMode string   `mapstructure:"email-failed-txs"`
+	EmailTxFields      []string `mapstructure:"email-tx-fields"`
+
+	AlertProviderName  string      `mapstructure:"alert-provider"`
+	AlertKey           string      `mapstructure:"alert-key"`
+	AlertURL           string      `mapstructure:"alert-url"`
+	AlertFailedTxsMode string      `mapstructure:"alert-failed-txs"`
+	AlertRules         []AlertRule `mapstructure:"alert-rules"`
+
+	WebhookURLs          []string `mapstructure:"webhook-url"`
+	WebhookSecret        string   `mapstructure:"webhook-secret"`
+	WebhookMaxRetries    int      `mapstructure:"webhook-max-retries"`
+	WebhookFailedTxsMode string   `mapstructure:"webhook-failed-txs"`
+
+	StdoutEnabled       bool     `mapstructure:"stdout"`
+	StdoutFormat        string   `mapstructure:"stdout-format"`
+	StdoutFailedTxsMode string   `mapstructure:"stdout-failed-txs"`
+	StdoutTxFields      []string `mapstructure:"stdout-tx-fields"`
+}
+
+func defaultReporterConfig(reporterType string) ReporterConfig {
+	return ReporterConfig{
+		Name: reporterType,
+		Type: reporterType,
+
+		TelegramToken:              TelegramToken,
+		TelegramChat:               TelegramChat,
+		TelegramAdmins:             TelegramAdmins,
+		TelegramSetAliasCommand:    TelegramSetAliasCommand,
+		TelegramClearAliasCommand:  TelegramClearAliasCommand,
+		TelegramListAliasesCommand: TelegramListAliasesCommand,
+		TelegramListQueriesCommand: TelegramListQueriesCommand,
+		TelegramAddQueryCommand:    TelegramAddQueryCommand,
+		TelegramRemoveQueryCommand: TelegramRemoveQueryCommand,
+		TelegramFailedTxsMode:      TelegramFailedTxsMode,
+		TelegramTxFields:           TelegramTxFields,
+
+		SlackToken:              SlackToken,
+		SlackChat:               SlackChat,
+		SlackSigningSecret:      SlackSigningSecret,
+		SlackListenAddress:      SlackListenAddress,
+		SlackAdmins:             SlackAdmins,
+		SlackSetAliasCommand:    SlackSetAliasCommand,
+		SlackClearAliasCommand:  SlackClearAliasCommand,
+		SlackListAliasesCommand: SlackListAliasesCommand,
+		SlackListQueriesCommand: SlackListQueriesCommand,
+		SlackAddQueryCommand:    SlackAddQueryCommand,
+		SlackRemoveQueryCommand: SlackRemoveQueryCommand,
+		SlackFailedTxsMode:      SlackFailedTxsMode,
+		SlackTxFields:           SlackTxFields,
+
+		DiscordToken:              DiscordToken,
+		DiscordChannel:            DiscordChannel,
+		DiscordWebhookURL:         DiscordWebhookURL,
+		DiscordGuild:              DiscordGuild,
+		DiscordAdmins:             DiscordAdmins,
+		DiscordSetAliasCommand:    DiscordSetAliasCommand,
+		DiscordClearAliasCommand:  DiscordClearAliasCommand,
+		DiscordListAliasesCommand: DiscordListAliasesCommand,
+		DiscordFailedTxsMode:      DiscordFailedTxsMode,
+		DiscordTxFields:           DiscordTxFields,
+
+		MatrixHomeserver:         MatrixHomeserver,
+		MatrixAccessToken:        MatrixAccessToken,
+		MatrixRoom:               MatrixRoom,
+		MatrixAdmins:             MatrixAdmins,
+		MatrixSetAliasCommand:    MatrixSetAliasCommand,
+		MatrixClearAliasCommand:  MatrixClearAliasCommand,
+		MatrixListAliasesCommand: MatrixListAliasesCommand,
+		MatrixFailedTxsMode:      MatrixFailedTxsMode,
+		MatrixTxFields:           MatrixTxFields,
+
+		EmailHost:          EmailHost,
+		EmailPort:          EmailPort,
+		EmailUsername:      EmailUsername,
+		EmailPassword:      EmailPassword,
+		EmailTLSMode:       EmailTLSMode,
+		EmailFrom:          EmailFrom,
+		EmailTo:            EmailTo,
+		EmailFailedTxsMode: EmailFailedTxsMode,
+		EmailTxFields:      EmailTxFields,
+
+		AlertProviderName:  AlertProviderName,
+		AlertKey:           AlertKey,
+		AlertURL:           AlertURL,
+		AlertFailedTxsMode: AlertFailedTxsMode,
+		AlertRules:         loadAlertRules(),
+
+		WebhookURLs:          WebhookURLs,
+		WebhookSecret:        WebhookSecret,
+		WebhookMaxRetries:    WebhookMaxRetries,
+		WebhookFailedTxsMode: WebhookFailedTxsMode,
+
+		// a stdout reporter defined in config is there to print something
+		StdoutEnabled:       StdoutEnabled || reporterType == "stdout" && viper.IsSet("reporters"),
+		StdoutFormat:        StdoutFormat,
+		StdoutFailedTxsMode: StdoutFailedTxsMode,
+		StdoutTxFields:      StdoutTxFields,
+	}
+}
+
+// loadReporterConfigs reads the reporters list from the config file. If there is none,
+// a reporter of each type is built out of the flat flags, so old configs keep working.
+func loadReporterConfigs() []ReporterConfig {
+	if !viper.IsSet("reporters") {
+		log.Debug().Msg("No reporters are configured, using reporters from flags.")
+
+		configs := make([]ReporterConfig, len(reporterTypes))
+		for index, reporterType := range reporterTypes {
+			configs[index] = defaultReporterConfig(reporterType)
+		}
+
+		return configs
+	}
+
+	var rawConfigs []map[string]interface{}
+	if err := viper.UnmarshalKey("reporters", &rawConfigs); err != nil {
+		log.Fatal().Err(err).Msg("Could not parse reporters config")
+	}
+
+	configs := make([]ReporterConfig, len(rawConfigs))
+	names := make(map[string]bool)
+
+	for index, rawConfig := range rawConfigs {
+		reporterType, _ := rawConfig["type"].(string)
+		if !contains(reporterTypes, reporterType) {
+			log.Fatal().
+				Int("index", index).
+				Str("type", reporterType).
+				Strs("expected", reporterTypes).
+				Msg("Invalid reporter type")
+		}
+
+		config := defaultReporterConfig(reporterType)
+		config.Name = ""
+
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			WeaklyTypedInput: true,
+			DecodeHook:       mapstructure.StringToSliceHookFunc(","),
+			Result:           &config,
+		})
+		if err != nil {
+			log.Fatal().Err(err).Msg("Could not create reporter config decoder")
+		}
+
+		if err := decoder.Decode(rawConfig); err != nil {
+			log.Fatal().Err(err).Int("index", index).Msg("Could not parse reporter config")
+		}
+
+		if config.Name == "" {
+			log.Fatal().Int("index", index).Msg("Reporter name is not set")
+		}
+
+		if _, ok := names[config.Name]; ok {
+			log.Fatal().Str("name", config.Name).Msg("Reporter name is used more than once")
+		}
+
+		// the named queries come from the config keys, which viper lowercases,
+		// and the runtime ones are lowercased too
+		for queryIndex, query := range config.Queries {
+			config.Queries[queryIndex] = strings.ToLower(query)
+		}
+
+		names[config.Name] = true
+		configs[index] = config
+	}
+
+	return configs
+}
+
+// checkReporterQueries warns about the reporters queries that no chain has, as these reporters
+// would not receive anything for them, which is most likely a typo. It's not fatal, as
+// the query can be added later with a command.
+func checkReporterQueries() {
+	known := []string{}
+	for _, chain := range Chains {
+		for _, query := range chain.getQueries() {
+			if query.Name != "" && !contains(known, query.Name) {
+				known = append(known, query.Name)
+			}
+		}
+	}
+
+	for _, reporter := range reporters {
+		for _, query := range reporter.Queries() {
+			if !contains(known, query) {
+				log.Warn().
+					Str("reporter", reporter.Name()).
+					Str("query", query).
+					Strs("known", known).
+					Msg("Reporter query is not defined for any chain, the reporter won't get txs for it")
+			}
+		}
+	}
+}
+
+func (c ReporterConfig) newReporter() Reporter {
+	switch c.Type {
+	case "telegram":
+		return &TelegramReporter{
+			ReporterName:               c.Name,
+			ReporterQueries:            c.Queries,
+			ReporterFilter:             mustParseFilter(c.Filter, "reporter", c.Name),
+			TelegramToken:              c.TelegramToken,
+			TelegramChat:               c.TelegramChat,
+			TelegramAdmins:             c.TelegramAdmins,
+			TelegramSetAliasCommand:    c.TelegramSetAliasCommand,
+			TelegramClearAliasCommand:  c.TelegramClearAliasCommand,
+			TelegramListAliasesCommand: c.TelegramListAliasesCommand,
+			TelegramListQueriesCommand: c.TelegramListQueriesCommand,
+			TelegramAddQueryCommand:    c.TelegramAddQueryCommand,
+			TelegramRemoveQueryCommand: c.TelegramRemoveQueryCommand,
+			TelegramFailedTxsMode:      parseFailedTxsMode(c.TelegramFailedTxsMode),
+			TelegramTxFields:           parseTxFields(c.TelegramTxFields),
+		}
+	case "slack":
+		return &SlackReporter{
+			ReporterName:            c.Name,
+			ReporterQueries:         c.Queries,
+			ReporterFilter:          mustParseFilter(c.Filter, "reporter", c.Name),
+			SlackToken:              c.SlackToken,
+			SlackChat:               c.SlackChat,
+			SlackSigningSecret:      c.SlackSigningSecret,
+			SlackListenAddress:      c.SlackListenAddress,
+			SlackAdmins:             c.SlackAdmins,
+			SlackSetAliasCommand:    c.SlackSetAliasCommand,
+			SlackClearAliasCommand:  c.SlackClearAliasCommand,
+			SlackListAliasesCommand: c.SlackListAliasesCommand,
+			SlackListQueriesCommand: c.SlackListQueriesCommand,
+			SlackAddQueryCommand:    c.SlackAddQueryCommand,
+			SlackRemoveQueryCommand: c.SlackRemoveQueryCommand,
+			SlackFailedTxsMode:      parseFailedTxsMode(c.SlackFailedTxsMode),
+			SlackTxFields:           parseTxFields(c.SlackTxFields),
+		}
+	case "discord":
+		return &DiscordReporter{
+			ReporterName:              c.Name,
+			ReporterQueries:           c.Queries,
+			ReporterFilter:            mustParseFilter(c.Filter, "reporter", c.Name),
+			DiscordToken:              c.DiscordToken,
+			DiscordChannel:            c.DiscordChannel,
+			DiscordWebhookURL:         c.DiscordWebhookURL,
+			DiscordGuild:              c.DiscordGuild,
+			DiscordAdmins:             c.DiscordAdmins,
+			DiscordSetAliasCommand:    c.DiscordSetAliasCommand,
+			DiscordClearAliasCommand:  c.DiscordClearAliasCommand,
+			DiscordListAliasesCommand: c.DiscordListAliasesCommand,
+			DiscordFailedTxsMode:      parseFailedTxsMode(c.DiscordFailedTxsMode),
+			DiscordTxFields:           parseTxFields(c.DiscordTxFields),
+		}
+	case "matrix":
+		return &MatrixReporter{
+			ReporterName:             c.Name,
+			ReporterQueries:          c.Queries,
+			ReporterFilter:           mustParseFilter(c.Filter, "reporter", c.Name),
+			MatrixHomeserver:         c.MatrixHomeserver,
+			MatrixAccessToken:        c.MatrixAccessToken,
+			MatrixRoom:               c.MatrixRoom,
+			MatrixAdmins:             c.MatrixAdmins,
+			MatrixSetAliasCommand:    c.MatrixSetAliasCommand,
+			MatrixClearAliasCommand:  c.MatrixClearAliasCommand,
+			MatrixListAliasesCommand: c.MatrixListAliasesCommand,
+			MatrixFailedTxsMode:      parseFailedTxsMode(c.MatrixFailedTxsMode),
+			MatrixTxFields:           parseTxFields(c.MatrixTxFields),
+		}
+	case "email":
+		return &EmailReporter{
+			ReporterName:       c.Name,
+			ReporterQueries:    c.Queries,
+			ReporterFilter:     mustParseFilter(c.Filter, "reporter", c.Name),
+			EmailHost:          c.EmailHost,
+			EmailPort:          c.EmailPort,
+			EmailUsername:      c.EmailUsername,
+			EmailPassword:      c.EmailPassword,
+			EmailTLSMode:       parseSmtpTLSMode(c.EmailTLSMode),
+			EmailFrom:          c.EmailFrom,
+			EmailTo:            c.EmailTo,
+			EmailFailedTxsMode: parseFailedTxsMode(c.EmailFailedTxsMode),
+			EmailTxFields:      parseTxFields(c.EmailTxFields),
+		}
+	case "alert":
+		return &AlertReporter{
+			ReporterName:       c.Name,
+			ReporterQueries:    c.Queries,
+			ReporterFilter:     mustParseFilter(c.Filter, "reporter", c.Name),
+			AlertProvider:      parseAlertProvider(c.AlertProviderName),
+			AlertKey:           c.AlertKey,
+			AlertURL:           c.AlertURL,
+			AlertRules:         validateAlertRules(c.AlertRules),
+			AlertFailedTxsMode: parseFailedTxsMode(c.AlertFailedTxsMode),
+		}
+	case "webhook":
+		return &WebhookReporter{
+			ReporterName:         c.Name,
+			ReporterQueries:      c.Queries,
+			ReporterFilter:       mustParseFilter(c.Filter, "reporter", c.Name),
+			WebhookURLs:          c.WebhookURLs,
+			WebhookSecret:        c.WebhookSecret,
+			WebhookMaxRetries:    c.WebhookMaxRetries,
+			WebhookFailedTxsMode: parseFailedTxsMode(c.WebhookFailedTxsMode),
+		}
+	default:
+		return &StdoutReporter{
+			ReporterName:        c.Name,
+			ReporterQueries:     c.Queries,
+			ReporterFilter:      mustParseFilter(c.Filter, "reporter", c.Name),
+			StdoutEnabled:       c.StdoutEnabled || DryRun,
+			StdoutFormat:        c.StdoutFormat,
+			StdoutFailedTxsMode: parseFailedTxsMode(c.StdoutFailedTxsMode),
+			StdoutTxFields:      parseTxFields(c.StdoutTxFields),
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// slackEscaper escapes the characters Slack uses for links and mentions, as Slack
+// requires them to be escaped even inside the code blocks.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// discordCodeEscaper puts a zero-width joiner after each backtick, so the memos and messages
+// JSON can't close the code block they are in, which would break the whole embed.
+var discordCodeEscaper = strings.NewReplacer("`", "`\u200d")
+
+// NewHtmlSerializer returns a serializer producing the HTML that Telegram understands.
+func NewHtmlSerializer(txFields TxFields) Serializer {
+	return Serializer{
+		// all the text is escaped, as the labels and the chat commands arguments can have anything
+		// in them, and the emails and Matrix messages are rendered as regular HTML
+		LinksSerializer: func(address string, text string) string {
+			return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(address), html.EscapeString(text))
+		},
+		StrongSerializer: func(text string) string {
+			return fmt.Sprintf(`<strong>%s</strong>`, html.EscapeString(text))
+		},
+		// the code blocks have the memos, logs and messages JSON, which can have anything in them,
+		// and Telegram rejects the whole message if they are not escaped
+		CodeSerializer: func(text string) string {
+			return fmt.Sprintf(`<code>%s</code>`, html.EscapeString(text))
+		},
+		MultilineCodeSerializer: func(text string) string {
+			return fmt.Sprintf(`<pre>%s</pre>`, html.EscapeString(text))
+		},
+		TxFields: txFields,
+	}
+}
+
+// NewMarkdownSerializer returns a serializer producing Slack's flavour of Markdown.
+func NewMarkdownSerializer(txFields TxFields) Serializer {
+	return Serializer{
+		LinksSerializer: func(address string, text string) string {
+			return fmt.Sprintf(`<%s|%s>`, address, text)
+		},
+		StrongSerializer: func(text string) string {
+			return fmt.Sprintf(`*%s*`, text)
+		},
+		CodeSerializer: func(text string) string {
+			return fmt.Sprintf("`%s`", slackEscaper.Replace(text))
+		},
+		MultilineCodeSerializer: func(text string) string {
+			return fmt.Sprintf("```\n%s\n```", slackEscaper.Replace(text))
+		},
+		TxFields: txFields,
+	}
+}
+
+// NewPlainSerializer returns a serializer producing plain text, with links shown as is.
+func NewPlainSerializer(txFields TxFields) Serializer {
+	return Serializer{
+		LinksSerializer: func(address string, text string) string {
+			return fmt.Sprintf("%s (%s)", text, address)
+		},
+		StrongSerializer: func(text string) string {
+			return text
+		},
+		CodeSerializer: func(text string) string {
+			return text
+		},
+		MultilineCodeSerializer: func(text string) string {
+			return text
+		},
+		TxFields: txFields,
+	}
+}
+
+// NewDiscordMarkdownSerializer returns a serializer producing Discord's flavour of Markdown.
+func NewDiscordMarkdownSerializer(txFields TxFields) Serializer {
+	return Serializer{
+		LinksSerializer: func(address string, text string) string {
+			return fmt.Sprintf(`[%s](%s)`, text, address)
+		},
+		StrongSerializer: func(text string) string {
+			return fmt.Sprintf(`**%s**`, text)
+		},
+		CodeSerializer: func(text string) string {
+			// single backticks can only be inside the code wrapped in double ones
+			if strings.Contains(text, "`") {
+				return fmt.Sprintf("`` %s ``", discordCodeEscaper.Replace(text))
+			}
+
+			return fmt.Sprintf("`%s`", text)
+		},
+		MultilineCodeSerializer: func(text string) string {
+			return fmt.Sprintf("```\n%s\n```", discordCodeEscaper.Replace(text))
+		},
+		TxFields: txFields,
+	}
+}
+
+// getHtmlWithLineBreaks converts newlines to line breaks, as unlike Telegram, Matrix
+// clients and email clients render the HTML serializer output as regular HTML.
+func getHtmlWithLineBreaks(text string) string {
+	return strings.ReplaceAll(strings.TrimSpace(text), "\n", "<br>")
+}
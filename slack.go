@@ -1,38 +1,51 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/slack-go/slack"
 )
 
 type SlackReporter struct {
+	ReporterName    string
+	ReporterQueries []string
+	ReporterFilter  *Filter
+
 	SlackToken         string
 	SlackChat          string
 	SlackSigningSecret string
 	SlackListenAddress string
+	SlackAdmins        []string
 
 	SlackSetAliasCommand    string
 	SlackClearAliasCommand  string
 	SlackListAliasesCommand string
+	SlackListQueriesCommand string
+	SlackAddQueryCommand    string
+	SlackRemoveQueryCommand string
+
+	SlackFailedTxsMode FailedTxsMode
+	SlackTxFields      TxFields
 
 	SlackClient        slack.Client
 	MarkdownSerializer Serializer
-	CacheManager       *CacheManager
 }
 
 func (r SlackReporter) Serialize(report Report) string {
 	var sb strings.Builder
 
-	sb.WriteString(report.Tx.Serialize(r.Serializer()) + "\n\n")
+	serializer := r.Serializer().withChain(report.Chain)
+
+	sb.WriteString(report.Tx.Serialize(serializer) + "\n\n")
 
 	for _, msg := range report.Msgs {
-		sb.WriteString(msg.Serialize(r.Serializer()) + "\n\n")
+		sb.WriteString(msg.Serialize(serializer) + "\n\n")
 	}
 
 	return sb.String()
@@ -46,96 +59,194 @@ func (r *SlackReporter) Init() {
 
 	client := slack.New(r.SlackToken)
 	r.SlackClient = *client
-	r.MarkdownSerializer = Serializer{
-		LinksSerializer: func(address string, text string) string {
-			return fmt.Sprintf(`<%s|%s>`, address, text)
-		},
-		StrongSerializer: func(text string) string {
-			return fmt.Sprintf(`*%s*`, text)
-		},
-		CodeSerializer: func(text string) string {
-			return fmt.Sprintf("`%s`", text)
-		},
-		MultilineCodeSerializer: func(text string) string {
-			return fmt.Sprintf("```\n%s\n```", text)
-		},
-		CacheManager: r.CacheManager,
-	}
+	r.MarkdownSerializer = NewMarkdownSerializer(r.SlackTxFields)
 
 	go r.InitSlashHandler()
 }
 
+// SlackSlashServer handles the slash commands for all the Slack reporters listening on the same
+// address, as only one handler can listen on it. Each command is handled by the first reporter
+// whose signing secret it is signed with and which accepts commands from its channel or user,
+// so it's answered once.
+type SlackSlashServer struct {
+	reporters []*SlackReporter
+	mutex     sync.RWMutex
+}
+
+var (
+	slackSlashServers      = make(map[string]*SlackSlashServer)
+	slackSlashServersMutex sync.Mutex
+)
+
 func (reporter *SlackReporter) InitSlashHandler() {
 	if reporter.SlackSigningSecret == "" {
 		log.Debug().Msg("Slack signing secret not set, not exposing slash commands handler.")
 		return
 	}
 
-	http.HandleFunc("/slash", func(w http.ResponseWriter, r *http.Request) {
-		verifier, err := slack.NewSecretsVerifier(r.Header, reporter.SlackSigningSecret)
+	slackSlashServersMutex.Lock()
+	server, found := slackSlashServers[reporter.SlackListenAddress]
+	if !found {
+		server = &SlackSlashServer{}
+		slackSlashServers[reporter.SlackListenAddress] = server
+	}
+	slackSlashServersMutex.Unlock()
+
+	server.addReporter(reporter)
+
+	if found {
+		log.Debug().
+			Str("address", reporter.SlackListenAddress).
+			Str("reporter", reporter.ReporterName).
+			Msg("Slack slash commands handler is already listening on this address, sharing it")
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slash", server.handle)
+
+	log.Info().Str("address", reporter.SlackListenAddress).Msg("Slack slash commands handler is listening")
+	if err := http.ListenAndServe(reporter.SlackListenAddress, mux); err != nil {
+		log.Fatal().Err(err).Msg("Could not start Slack slash commands handler")
+	}
+}
+
+func (server *SlackSlashServer) addReporter(reporter *SlackReporter) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.reporters = append(server.reporters, reporter)
+}
+
+// findReporter returns the first reporter the request is signed for that accepts the command,
+// and whether the request is signed for any reporter at all.
+func (server *SlackSlashServer) findReporter(header http.Header, body []byte, s slack.SlashCommand) (*SlackReporter, bool) {
+	server.mutex.RLock()
+	defer server.mutex.RUnlock()
+
+	verified := false
+
+	for _, reporter := range server.reporters {
+		verifier, err := slack.NewSecretsVerifier(header, reporter.SlackSigningSecret)
 		if err != nil {
-			log.Warn().Err(err).Msg("Could not create Slack secrets verifier.")
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+			log.Warn().Err(err).Str("reporter", reporter.ReporterName).Msg("Could not create Slack secrets verifier.")
+			continue
 		}
 
-		r.Body = ioutil.NopCloser(io.TeeReader(r.Body, &verifier))
-		s, err := slack.SlashCommandParse(r)
-		if err != nil {
-			log.Warn().Err(err).Msg("Could not parse Slack slash command.")
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+		if _, err := verifier.Write(body); err != nil {
+			log.Warn().Err(err).Str("reporter", reporter.ReporterName).Msg("Could not verify Slack slash command request.")
+			continue
 		}
 
-		if err = verifier.Ensure(); err != nil {
-			log.Warn().Err(err).Msg("Could not verify Slack slash command request.")
-			w.WriteHeader(http.StatusUnauthorized)
-			return
+		if err := verifier.Ensure(); err != nil {
+			continue
 		}
 
-		log.Info().
+		verified = true
+
+		if reporter.isAllowed(s) {
+			return reporter, true
+		}
+	}
+
+	return nil, verified
+}
+
+// isAllowed checks if the command is sent to the reports channel or by an admin, as the slash
+// commands can be used in any channel of the workspace. The channel can be set by its name.
+func (reporter *SlackReporter) isAllowed(s slack.SlashCommand) bool {
+	if s.ChannelID == reporter.SlackChat || s.ChannelName == strings.TrimPrefix(reporter.SlackChat, "#") {
+		return true
+	}
+
+	return contains(reporter.SlackAdmins, s.UserID)
+}
+
+func (server *SlackSlashServer) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not read Slack slash command.")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	s, err := slack.SlashCommandParse(r)
+	if err != nil {
+		log.Warn().Err(err).Msg("Could not parse Slack slash command.")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	reporter, verified := server.findReporter(r.Header, body, s)
+	if !verified {
+		log.Warn().Msg("Could not verify Slack slash command request.")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	if reporter == nil {
+		log.Warn().
 			Str("command", s.Command).
-			Str("text", s.Text).
 			Str("channel", s.ChannelName).
 			Str("user", s.UserName).
-			Msg("Received command")
-
-		switch s.Command {
-		case reporter.SlackSetAliasCommand:
-			reporter.processSetAliasCommand(s, w)
-		case reporter.SlackClearAliasCommand:
-			reporter.processClearAliasCommand(s, w)
-		case reporter.SlackListAliasesCommand:
-			reporter.processListAliasesCommand(s, w)
-		default:
-			log.Debug().Msg("Unsupported command, skipping.")
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+			Msg("Received command from a channel or user that is not allowed, skipping")
 
-		log.Debug().Msg("Slash command processed.")
-	})
+		if err := writeMessageWithType("Commands are not allowed here.", slack.ResponseTypeEphemeral, w); err != nil {
+			log.Error().Err(err).Str("command", s.Command).Msg("Could not send response to Slack command")
+		}
+		return
+	}
 
-	log.Info().Str("address", reporter.SlackListenAddress).Msg("Slack slash commands handler is listening")
-	if err := http.ListenAndServe(reporter.SlackListenAddress, nil); err != nil {
-		log.Fatal().Err(err).Msg("Could not start Slack slash commands handler")
+	log.Info().
+		Str("command", s.Command).
+		Str("text", s.Text).
+		Str("channel", s.ChannelName).
+		Str("user", s.UserName).
+		Str("reporter", reporter.ReporterName).
+		Msg("Received command")
+
+	switch s.Command {
+	case reporter.SlackSetAliasCommand:
+		reporter.processSetAliasCommand(s, w)
+	case reporter.SlackClearAliasCommand:
+		reporter.processClearAliasCommand(s, w)
+	case reporter.SlackListAliasesCommand:
+		reporter.processListAliasesCommand(s, w)
+	case reporter.SlackListQueriesCommand:
+		reporter.processListQueriesCommand(w)
+	case reporter.SlackAddQueryCommand:
+		reporter.processAddQueryCommand(s, w)
+	case reporter.SlackRemoveQueryCommand:
+		reporter.processRemoveQueryCommand(s, w)
+	default:
+		log.Debug().Msg("Unsupported command, skipping.")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
+
+	log.Debug().Msg("Slash command processed.")
 }
 
 func (reporter *SlackReporter) processSetAliasCommand(s slack.SlashCommand, w http.ResponseWriter) {
-	text := fmt.Sprintf("Usage: `%s` &lt;wallet-address&gt; &lt;alias&gt;", reporter.SlackSetAliasCommand)
+	text := fmt.Sprintf(
+		"Usage: `%s` %s&lt;wallet-address&gt; &lt;alias&gt;",
+		reporter.SlackSetAliasCommand,
+		getChainCommandUsage(),
+	)
 
-	args := strings.SplitN(s.Text, " ", 2)
+	chain, argsText, found := findChainForCommand(s.Text)
+	args := strings.SplitN(argsText, " ", 2)
 
-	if len(args) >= 2 {
-		labelsConfigManager.setWalletLabel(args[0], args[1])
+	if found && len(args) >= 2 {
+		chain.LabelsConfigManager.setWalletLabel(args[0], args[1])
 		text = fmt.Sprintf(
 			"Successfully set alias for %s: %s",
-			reporter.MarkdownSerializer.LinksSerializer(makeMintscanAccountLink(args[0]), args[0]),
+			reporter.MarkdownSerializer.LinksSerializer(chain.makeMintscanAccountLink(args[0]), args[0]),
 			reporter.MarkdownSerializer.CodeSerializer(args[1]),
 		)
 	} else {
-		log.Info().Msg("/set-alias: args length < 2")
+		log.Info().Msg("/set-alias: args length < 2 or chain not found")
 	}
 
 	if err := writeMessage(text, w); err != nil {
@@ -144,16 +255,22 @@ func (reporter *SlackReporter) processSetAliasCommand(s slack.SlashCommand, w ht
 }
 
 func (reporter *SlackReporter) processClearAliasCommand(s slack.SlashCommand, w http.ResponseWriter) {
-	text := fmt.Sprintf("Usage: `%s` &lt;wallet-address&gt;", reporter.SlackClearAliasCommand)
+	text := fmt.Sprintf(
+		"Usage: `%s` %s&lt;wallet-address&gt;",
+		reporter.SlackClearAliasCommand,
+		getChainCommandUsage(),
+	)
+
+	chain, address, found := findChainForCommand(s.Text)
 
-	if strings.TrimSpace(s.Text) != "" {
-		labelsConfigManager.clearWalletLabel(s.Text)
+	if found && address != "" {
+		chain.LabelsConfigManager.clearWalletLabel(address)
 		text = fmt.Sprintf(
 			"Successfully cleared alias for %s",
-			reporter.MarkdownSerializer.LinksSerializer(makeMintscanAccountLink(s.Text), s.Text),
+			reporter.MarkdownSerializer.LinksSerializer(chain.makeMintscanAccountLink(address), address),
 		)
 	} else {
-		log.Info().Msg("/clear-alias: args length == ''")
+		log.Info().Msg("/clear-alias: args length == '' or chain not found")
 	}
 
 	if err := writeMessage(text, w); err != nil {
@@ -163,21 +280,27 @@ func (reporter *SlackReporter) processClearAliasCommand(s slack.SlashCommand, w
 
 func (reporter *SlackReporter) processListAliasesCommand(s slack.SlashCommand, w http.ResponseWriter) {
 	var sb strings.Builder
-	sb.WriteString(reporter.MarkdownSerializer.StrongSerializer("Wallet aliases:") + "\n")
 
-	if len(labelsConfigManager.config.WalletLabels) == 0 {
-		sb.WriteString(fmt.Sprintf(
-			"No label aliases are set. You can set one using `%s` &lt;wallet-address&gt;",
-			reporter.SlackSetAliasCommand,
-		))
-	}
+	for _, chain := range Chains {
+		sb.WriteString(reporter.MarkdownSerializer.StrongSerializer(
+			fmt.Sprintf("Wallet aliases on %s:", chain.Name),
+		) + "\n")
+
+		if len(chain.LabelsConfigManager.config.WalletLabels) == 0 {
+			sb.WriteString(fmt.Sprintf(
+				"No label aliases are set. You can set one using `%s` %s&lt;wallet-address&gt; &lt;alias&gt;\n",
+				reporter.SlackSetAliasCommand,
+				getChainCommandUsage(),
+			))
+		}
 
-	for key, value := range labelsConfigManager.config.WalletLabels {
-		sb.WriteString(fmt.Sprintf(
-			"• %s: %s\n",
-			reporter.MarkdownSerializer.LinksSerializer(makeMintscanAccountLink(key), key),
-			reporter.MarkdownSerializer.CodeSerializer(value),
-		))
+		for key, value := range chain.LabelsConfigManager.config.WalletLabels {
+			sb.WriteString(fmt.Sprintf(
+				"• %s: %s\n",
+				reporter.MarkdownSerializer.LinksSerializer(chain.makeMintscanAccountLink(key), key),
+				reporter.MarkdownSerializer.CodeSerializer(value),
+			))
+		}
 	}
 
 	if err := writeMessage(sb.String(), w); err != nil {
@@ -185,10 +308,38 @@ func (reporter *SlackReporter) processListAliasesCommand(s slack.SlashCommand, w
 	}
 }
 
+func (reporter *SlackReporter) processListQueriesCommand(w http.ResponseWriter) {
+	text := processListQueriesCommand(reporter.MarkdownSerializer)
+
+	if err := writeMessage(text, w); err != nil {
+		log.Error().Err(err).Msg("Could not send response to /list-queries command")
+	}
+}
+
+func (reporter *SlackReporter) processAddQueryCommand(s slack.SlashCommand, w http.ResponseWriter) {
+	text := processAddQueryCommand(reporter.MarkdownSerializer, reporter.SlackAddQueryCommand, s.Text)
+
+	if err := writeMessage(text, w); err != nil {
+		log.Error().Err(err).Msg("Could not send response to /add-query command")
+	}
+}
+
+func (reporter *SlackReporter) processRemoveQueryCommand(s slack.SlashCommand, w http.ResponseWriter) {
+	text := processRemoveQueryCommand(reporter.MarkdownSerializer, reporter.SlackRemoveQueryCommand, s.Text)
+
+	if err := writeMessage(text, w); err != nil {
+		log.Error().Err(err).Msg("Could not send response to /remove-query command")
+	}
+}
+
 func writeMessage(text string, w http.ResponseWriter) error {
+	return writeMessageWithType(text, slack.ResponseTypeInChannel, w)
+}
+
+func writeMessageWithType(text string, responseType string, w http.ResponseWriter) error {
 	params := &slack.Msg{
 		Text:         text,
-		ResponseType: "in_channel",
+		ResponseType: responseType,
 	}
 	b, err := json.Marshal(params)
 	if err != nil {
@@ -221,5 +372,17 @@ func (r SlackReporter) SendReport(report Report) error {
 }
 
 func (r SlackReporter) Name() string {
-	return "SlackReporter"
+	return r.ReporterName
+}
+
+func (r SlackReporter) FailedTxsMode() FailedTxsMode {
+	return r.SlackFailedTxsMode
+}
+
+func (r SlackReporter) Queries() []string {
+	return r.ReporterQueries
+}
+
+func (r SlackReporter) Filter() *Filter {
+	return r.ReporterFilter
 }
@@ -9,19 +9,24 @@ import (
 	"github.com/gogo/protobuf/proto"
 )
 
+func init() {
+	RegisterMsgParser("/cosmos.staking.v1beta1.MsgDelegate", ParseMsgDelegate)
+	RegisterMsgParser("/cosmos.staking.v1beta1.MsgUndelegate", ParseMsgUndelegate)
+	RegisterMsgParser("/cosmos.staking.v1beta1.MsgBeginRedelegate", ParseMsgBeginRedelegate)
+}
+
 type MsgDelegate struct {
 	DelegatorAddress string
 	ValidatorAddress string
 	Validator        cosmosStakingTypes.Validator
-	Denom            string
-	Amount           float64
+	Token            Coin
 }
 
 func (msg MsgDelegate) Empty() bool {
 	return msg.DelegatorAddress == ""
 }
 
-func ParseMsgDelegate(message *cosmosTypes.Any) MsgDelegate {
+func ParseMsgDelegate(message *cosmosTypes.Any, chain *Chain, block int64) Msg {
 	var parsedMessage cosmosStakingTypes.MsgDelegate
 	if err := proto.Unmarshal(message.Value, &parsedMessage); err != nil {
 		log.Error().Err(err).Msg("Could not parse MsgDelegate")
@@ -31,22 +36,20 @@ func ParseMsgDelegate(message *cosmosTypes.Any) MsgDelegate {
 	log.Info().
 		Str("from", parsedMessage.DelegatorAddress).
 		Str("to", parsedMessage.ValidatorAddress).
-		Str("denom", Denom).
-		Float64("amount", float64(parsedMessage.Amount.Amount.Int64())/DenomCoefficient).
+		Str("amount", parsedMessage.Amount.String()).
 		Msg("MsgDelegate")
 
 	return MsgDelegate{
 		DelegatorAddress: parsedMessage.DelegatorAddress,
 		ValidatorAddress: parsedMessage.ValidatorAddress,
-		Denom:            Denom,
-		Amount:           float64(parsedMessage.Amount.Amount.Int64()) / DenomCoefficient,
+		Token:            chain.getCoin(parsedMessage.Amount),
 	}
 }
 
 func (msg MsgDelegate) Serialize(serializer Serializer) string {
 	var sb strings.Builder
 	sb.WriteString(serializer.StrongSerializer("Delegate") + "\n")
-	sb.WriteString(serializer.getTokensMaybeWithDollarPrice(msg.Amount, msg.Denom) + "\n")
+	sb.WriteString(serializer.getCoinMaybeWithFiatPrice(msg.Token) + "\n")
 
 	sb.WriteString(fmt.Sprintf("%s %s\n",
 		serializer.StrongSerializer("From:"),
@@ -61,19 +64,40 @@ func (msg MsgDelegate) Serialize(serializer Serializer) string {
 	return sb.String()
 }
 
+func (msg MsgDelegate) Summary(chain *Chain) string {
+	return fmt.Sprintf(
+		"Delegate %s to %s",
+		getTokensSummary(msg.Token.Amount, msg.Token.DisplayDenom()),
+		chain.getValidatorSummary(msg.ValidatorAddress),
+	)
+}
+
+type JSONMsgDelegate struct {
+	Delegator JSONAddress   `json:"delegator"`
+	Validator JSONValidator `json:"validator"`
+	Amount    JSONAmount    `json:"amount"`
+}
+
+func (msg MsgDelegate) SerializeJSON(chain *Chain) interface{} {
+	return JSONMsgDelegate{
+		Delegator: chain.getJSONAddress(msg.DelegatorAddress),
+		Validator: chain.getJSONValidator(msg.ValidatorAddress),
+		Amount:    chain.getJSONCoin(msg.Token),
+	}
+}
+
 type MsgBeginRedelegate struct {
 	DelegatorAddress    string
 	ValidatorSrcAddress string
 	ValidatorDstAddress string
-	Denom               string
-	Amount              float64
+	Token               Coin
 }
 
 func (msg MsgBeginRedelegate) Empty() bool {
 	return msg.DelegatorAddress == ""
 }
 
-func ParseMsgBeginRedelegate(message *cosmosTypes.Any) MsgBeginRedelegate {
+func ParseMsgBeginRedelegate(message *cosmosTypes.Any, chain *Chain, block int64) Msg {
 	var parsedMessage cosmosStakingTypes.MsgBeginRedelegate
 	if err := proto.Unmarshal(message.Value, &parsedMessage); err != nil {
 		log.Error().Err(err).Msg("Could not parse MsgBeginRedelegate")
@@ -84,23 +108,21 @@ func ParseMsgBeginRedelegate(message *cosmosTypes.Any) MsgBeginRedelegate {
 		Str("by", parsedMessage.DelegatorAddress).
 		Str("from", parsedMessage.ValidatorSrcAddress).
 		Str("to", parsedMessage.ValidatorDstAddress).
-		Str("denom", Denom).
-		Float64("amount", float64(parsedMessage.Amount.Amount.Int64())/DenomCoefficient).
+		Str("amount", parsedMessage.Amount.String()).
 		Msg("MsgBeginRedelegate")
 
 	return MsgBeginRedelegate{
 		DelegatorAddress:    parsedMessage.DelegatorAddress,
 		ValidatorSrcAddress: parsedMessage.ValidatorSrcAddress,
 		ValidatorDstAddress: parsedMessage.ValidatorDstAddress,
-		Denom:               Denom,
-		Amount:              float64(parsedMessage.Amount.Amount.Int64()) / DenomCoefficient,
+		Token:               chain.getCoin(parsedMessage.Amount),
 	}
 }
 
 func (msg MsgBeginRedelegate) Serialize(serializer Serializer) string {
 	var sb strings.Builder
 	sb.WriteString(serializer.StrongSerializer("Redelegate") + "\n")
-	sb.WriteString(serializer.getTokensMaybeWithDollarPrice(msg.Amount, msg.Denom) + "\n")
+	sb.WriteString(serializer.getCoinMaybeWithFiatPrice(msg.Token) + "\n")
 
 	sb.WriteString(fmt.Sprintf("%s %s\n",
 		serializer.StrongSerializer("By:"),
@@ -120,18 +142,42 @@ func (msg MsgBeginRedelegate) Serialize(serializer Serializer) string {
 	return sb.String()
 }
 
+func (msg MsgBeginRedelegate) Summary(chain *Chain) string {
+	return fmt.Sprintf(
+		"Redelegate %s from %s to %s",
+		getTokensSummary(msg.Token.Amount, msg.Token.DisplayDenom()),
+		chain.getValidatorSummary(msg.ValidatorSrcAddress),
+		chain.getValidatorSummary(msg.ValidatorDstAddress),
+	)
+}
+
+type JSONMsgBeginRedelegate struct {
+	Delegator    JSONAddress   `json:"delegator"`
+	ValidatorSrc JSONValidator `json:"validator_src"`
+	ValidatorDst JSONValidator `json:"validator_dst"`
+	Amount       JSONAmount    `json:"amount"`
+}
+
+func (msg MsgBeginRedelegate) SerializeJSON(chain *Chain) interface{} {
+	return JSONMsgBeginRedelegate{
+		Delegator:    chain.getJSONAddress(msg.DelegatorAddress),
+		ValidatorSrc: chain.getJSONValidator(msg.ValidatorSrcAddress),
+		ValidatorDst: chain.getJSONValidator(msg.ValidatorDstAddress),
+		Amount:       chain.getJSONCoin(msg.Token),
+	}
+}
+
 type MsgUndelegate struct {
 	DelegatorAddress string
 	ValidatorAddress string
-	Denom            string
-	Amount           float64
+	Token            Coin
 }
 
 func (msg MsgUndelegate) Empty() bool {
 	return msg.DelegatorAddress == ""
 }
 
-func ParseMsgUndelegate(message *cosmosTypes.Any) MsgUndelegate {
+func ParseMsgUndelegate(message *cosmosTypes.Any, chain *Chain, block int64) Msg {
 	var parsedMessage cosmosStakingTypes.MsgUndelegate
 	if err := proto.Unmarshal(message.Value, &parsedMessage); err != nil {
 		log.Error().Err(err).Msg("Could not parse MsgUndelegate")
@@ -141,22 +187,20 @@ func ParseMsgUndelegate(message *cosmosTypes.Any) MsgUndelegate {
 	log.Info().
 		Str("from", parsedMessage.ValidatorAddress).
 		Str("by", parsedMessage.DelegatorAddress).
-		Str("denom", Denom).
-		Float64("amount", float64(parsedMessage.Amount.Amount.Int64())/DenomCoefficient).
+		Str("amount", parsedMessage.Amount.String()).
 		Msg("MsgUndelegate")
 
 	return MsgUndelegate{
 		DelegatorAddress: parsedMessage.DelegatorAddress,
 		ValidatorAddress: parsedMessage.ValidatorAddress,
-		Denom:            Denom,
-		Amount:           float64(parsedMessage.Amount.Amount.Int64()) / DenomCoefficient,
+		Token:            chain.getCoin(parsedMessage.Amount),
 	}
 }
 
 func (msg MsgUndelegate) Serialize(serializer Serializer) string {
 	var sb strings.Builder
 	sb.WriteString(serializer.StrongSerializer("Undelegate") + "\n")
-	sb.WriteString(serializer.getTokensMaybeWithDollarPrice(msg.Amount, msg.Denom) + "\n")
+	sb.WriteString(serializer.getCoinMaybeWithFiatPrice(msg.Token) + "\n")
 
 	sb.WriteString(fmt.Sprintf("%s %s\n",
 		serializer.StrongSerializer("From:"),
@@ -170,3 +214,25 @@ func (msg MsgUndelegate) Serialize(serializer Serializer) string {
 
 	return sb.String()
 }
+
+func (msg MsgUndelegate) Summary(chain *Chain) string {
+	return fmt.Sprintf(
+		"Undelegate %s from %s",
+		getTokensSummary(msg.Token.Amount, msg.Token.DisplayDenom()),
+		chain.getValidatorSummary(msg.ValidatorAddress),
+	)
+}
+
+type JSONMsgUndelegate struct {
+	Delegator JSONAddress   `json:"delegator"`
+	Validator JSONValidator `json:"validator"`
+	Amount    JSONAmount    `json:"amount"`
+}
+
+func (msg MsgUndelegate) SerializeJSON(chain *Chain) interface{} {
+	return JSONMsgUndelegate{
+		Delegator: chain.getJSONAddress(msg.DelegatorAddress),
+		Validator: chain.getJSONValidator(msg.ValidatorAddress),
+		Amount:    chain.getJSONCoin(msg.Token),
+	}
+}
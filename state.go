@@ -0,0 +1,363 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	ibctypes "github.com/cosmos/ibc-go/modules/apps/transfer/types"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	sentTxsBucket     = []byte("sent_txs")
+	lastHeightsBucket = []byte("last_heights")
+	queriesBucket     = []byte("queries")
+	denomTracesBucket = []byte("denom_traces")
+)
+
+// StateManager stores the state that should survive restarts, like the txs
+// that were already sent, the last processed heights, the queries added with commands
+// and the IBC denom traces, which never change once created. If the database path
+// is not set, the state is only kept in memory.
+type StateManager struct {
+	db *bolt.DB
+
+	sentTxs     map[string]time.Time
+	lastHeights map[string]int64
+	queries     map[string][]RuntimeQuery
+	denomTraces map[string]ibctypes.DenomTrace
+	mutex       sync.Mutex
+
+	sentTxsTTL      time.Duration
+	sentTxsMaxCount int
+}
+
+// isPersistent returns whether the state survives restarts.
+func (s *StateManager) isPersistent() bool {
+	return s.db != nil
+}
+
+func NewStateManager(path string, sentTxsTTL time.Duration, sentTxsMaxCount int) *StateManager {
+	manager := &StateManager{
+		sentTxs:         make(map[string]time.Time),
+		lastHeights:     make(map[string]int64),
+		queries:         make(map[string][]RuntimeQuery),
+		denomTraces:     make(map[string]ibctypes.DenomTrace),
+		sentTxsTTL:      sentTxsTTL,
+		sentTxsMaxCount: sentTxsMaxCount,
+	}
+
+	if path == "" {
+		log.Info().Msg("State path not provided, state would be kept in memory only.")
+	} else {
+		db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+		if err != nil {
+			log.Fatal().Err(err).Str("path", path).Msg("Could not open state database")
+		}
+
+		if err := db.Update(func(tx *bolt.Tx) error {
+			for _, bucket := range [][]byte{sentTxsBucket, lastHeightsBucket, queriesBucket, denomTracesBucket} {
+				if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}); err != nil {
+			log.Fatal().Err(err).Msg("Could not create state database buckets")
+		}
+
+		manager.db = db
+		log.Info().Str("path", path).Msg("Using state database")
+	}
+
+	go manager.pruneSentTxsPeriodically()
+	return manager
+}
+
+func (s *StateManager) Close() {
+	if s.db == nil {
+		return
+	}
+
+	if err := s.db.Close(); err != nil {
+		log.Error().Err(err).Msg("Could not close state database")
+	}
+}
+
+func getSentTxKey(chain string, hash string) string {
+	return chain + "/" + hash
+}
+
+func (s *StateManager) isTxSent(chain string, hash string) bool {
+	key := getSentTxKey(chain, hash)
+
+	if s.db == nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		_, found := s.sentTxs[key]
+		return found
+	}
+
+	found := false
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		found = tx.Bucket(sentTxsBucket).Get([]byte(key)) != nil
+		return nil
+	}); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Could not check if tx was sent")
+	}
+
+	return found
+}
+
+func (s *StateManager) setTxSent(chain string, hash string) {
+	key := getSentTxKey(chain, hash)
+	now := time.Now()
+
+	if s.db == nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.sentTxs[key] = now
+		return
+	}
+
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(now.UnixNano()))
+
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(sentTxsBucket).Put([]byte(key), value)
+	}); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Could not save sent tx")
+	}
+}
+
+func (s *StateManager) getLastHeight(chain string) int64 {
+	if s.db == nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		return s.lastHeights[chain]
+	}
+
+	var height int64
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(lastHeightsBucket).Get([]byte(chain)); value != nil {
+			height = int64(binary.BigEndian.Uint64(value))
+		}
+		return nil
+	}); err != nil {
+		log.Error().Err(err).Str("chain", chain).Msg("Could not get last height")
+	}
+
+	return height
+}
+
+// setLastHeight saves the height the chain is processed up to. It never goes back,
+// so txs arriving out of order do not cause the same blocks to be processed again.
+func (s *StateManager) setLastHeight(chain string, height int64) {
+	if s.db == nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if height > s.lastHeights[chain] {
+			s.lastHeights[chain] = height
+		}
+		return
+	}
+
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(lastHeightsBucket)
+
+		if value := bucket.Get([]byte(chain)); value != nil && int64(binary.BigEndian.Uint64(value)) >= height {
+			return nil
+		}
+
+		value := make([]byte, 8)
+		binary.BigEndian.PutUint64(value, uint64(height))
+		return bucket.Put([]byte(chain), value)
+	}); err != nil {
+		log.Error().Err(err).Str("chain", chain).Msg("Could not save last height")
+	}
+}
+
+func (s *StateManager) getQueries(chain string) []RuntimeQuery {
+	if s.db == nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		return s.queries[chain]
+	}
+
+	var queries []RuntimeQuery
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		if value := tx.Bucket(queriesBucket).Get([]byte(chain)); value != nil {
+			return json.Unmarshal(value, &queries)
+		}
+		return nil
+	}); err != nil {
+		log.Error().Err(err).Str("chain", chain).Msg("Could not get queries")
+	}
+
+	return queries
+}
+
+func (s *StateManager) setQueries(chain string, queries []RuntimeQuery) error {
+	if s.db == nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.queries[chain] = queries
+		return nil
+	}
+
+	value, err := json.Marshal(queries)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(queriesBucket).Put([]byte(chain), value)
+	})
+}
+
+func getDenomTraceKey(chain string, hash string) string {
+	return chain + "/" + hash
+}
+
+func (s *StateManager) getDenomTrace(chain string, hash string) (ibctypes.DenomTrace, bool) {
+	key := getDenomTraceKey(chain, hash)
+
+	if s.db == nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		trace, found := s.denomTraces[key]
+		return trace, found
+	}
+
+	var trace ibctypes.DenomTrace
+	found := false
+
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(denomTracesBucket).Get([]byte(key))
+		if value == nil {
+			return nil
+		}
+
+		found = true
+		return json.Unmarshal(value, &trace)
+	}); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Could not get denom trace")
+		return ibctypes.DenomTrace{}, false
+	}
+
+	return trace, found
+}
+
+func (s *StateManager) setDenomTrace(chain string, hash string, trace ibctypes.DenomTrace) {
+	key := getDenomTraceKey(chain, hash)
+
+	if s.db == nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.denomTraces[key] = trace
+		return
+	}
+
+	value, err := json.Marshal(trace)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Could not serialize denom trace")
+		return
+	}
+
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(denomTracesBucket).Put([]byte(key), value)
+	}); err != nil {
+		log.Error().Err(err).Str("key", key).Msg("Could not save denom trace")
+	}
+}
+
+func (s *StateManager) pruneSentTxsPeriodically() {
+	for {
+		s.pruneSentTxs()
+		time.Sleep(time.Minute)
+	}
+}
+
+// pruneSentTxs removes the txs that were sent more than TTL ago, and if there
+// are still more of them than allowed, removes the oldest ones.
+func (s *StateManager) pruneSentTxs() {
+	if s.db == nil {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.sentTxs = pruneSentTxsMap(s.sentTxs, s.sentTxsTTL, s.sentTxsMaxCount)
+		return
+	}
+
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(sentTxsBucket)
+		sentTxs := make(map[string]time.Time)
+
+		if err := bucket.ForEach(func(key []byte, value []byte) error {
+			sentTxs[string(key)] = time.Unix(0, int64(binary.BigEndian.Uint64(value)))
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		remaining := pruneSentTxsMap(sentTxs, s.sentTxsTTL, s.sentTxsMaxCount)
+
+		for key := range sentTxs {
+			if _, found := remaining[key]; found {
+				continue
+			}
+
+			if err := bucket.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		log.Error().Err(err).Msg("Could not prune sent txs")
+	}
+}
+
+func pruneSentTxsMap(sentTxs map[string]time.Time, ttl time.Duration, maxCount int) map[string]time.Time {
+	keys := make([]string, 0, len(sentTxs))
+
+	for key, sentAt := range sentTxs {
+		if time.Since(sentAt) <= ttl {
+			keys = append(keys, key)
+		}
+	}
+
+	if len(keys) > maxCount {
+		// newest first
+		sort.Slice(keys, func(i, j int) bool {
+			return sentTxs[keys[i]].After(sentTxs[keys[j]])
+		})
+		keys = keys[:maxCount]
+	}
+
+	result := make(map[string]time.Time, len(keys))
+	for _, key := range keys {
+		result[key] = sentTxs[key]
+	}
+
+	if len(result) != len(sentTxs) {
+		log.Debug().
+			Int("before", len(sentTxs)).
+			Int("after", len(result)).
+			Msg("Pruned sent txs")
+	}
+
+	return result
+}
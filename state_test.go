@@ -0,0 +1,88 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPruneSentTxsMap(t *testing.T) {
+	now := time.Now()
+
+	sentTxs := map[string]time.Time{
+		"old":    now.Add(-2 * time.Hour),
+		"newest": now.Add(-time.Minute),
+		"newer":  now.Add(-10 * time.Minute),
+		"new":    now.Add(-30 * time.Minute),
+	}
+
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		maxCount int
+		expected []string
+	}{
+		{"nothing expired", 24 * time.Hour, 10, []string{"old", "newest", "newer", "new"}},
+		{"expired", time.Hour, 10, []string{"newest", "newer", "new"}},
+		{"all expired", time.Second, 10, []string{}},
+		{"over max count", 24 * time.Hour, 2, []string{"newest", "newer"}},
+		{"expired and over max count", time.Hour, 1, []string{"newest"}},
+		{"max count is zero", 24 * time.Hour, 0, []string{}},
+	}
+
+	for _, test := range tests {
+		result := pruneSentTxsMap(sentTxs, test.ttl, test.maxCount)
+		if len(result) != len(test.expected) {
+			t.Errorf("%s: expected %d txs, got %d", test.name, len(test.expected), len(result))
+			continue
+		}
+
+		for _, key := range test.expected {
+			if sentAt, found := result[key]; !found || !sentAt.Equal(sentTxs[key]) {
+				t.Errorf("%s: expected %q to be kept", test.name, key)
+			}
+		}
+	}
+
+	if len(sentTxs) != 4 {
+		t.Errorf("expected the passed map not to be changed, got %d txs", len(sentTxs))
+	}
+}
+
+func TestSetLastHeight(t *testing.T) {
+	tests := []struct {
+		name     string
+		heights  []int64
+		expected int64
+	}{
+		{"never set", []int64{}, 0},
+		{"increasing", []int64{10, 11, 15}, 15},
+		{"out of order", []int64{10, 15, 12}, 15},
+		{"same height", []int64{15, 15}, 15},
+		{"lower after higher", []int64{100, 1}, 100},
+	}
+
+	for _, persistent := range []bool{false, true} {
+		for _, test := range tests {
+			path := ""
+			if persistent {
+				path = filepath.Join(t.TempDir(), "state.db")
+			}
+
+			manager := NewStateManager(path, time.Hour, 10)
+			for _, height := range test.heights {
+				manager.setLastHeight("cosmos", height)
+			}
+
+			if result := manager.getLastHeight("cosmos"); result != test.expected {
+				t.Errorf("%s (persistent: %t): expected %d, got %d", test.name, persistent, test.expected, result)
+			}
+
+			if result := manager.getLastHeight("osmosis"); result != 0 {
+				t.Errorf("%s (persistent: %t): expected other chain's height to be 0, got %d", test.name, persistent, result)
+			}
+
+			manager.Close()
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// StaticPriceProvider takes the prices from a JSON file of base denom to price,
+// like {"uatom": 10.5}. The file is read again when it's changed, so the prices
+// can be updated by hand or by a script without restarting the bot.
+type StaticPriceProvider struct {
+	path        string
+	prices      map[string]float64
+	lastModTime time.Time
+	mutex       sync.Mutex
+}
+
+func NewStaticPriceProvider(path string) *StaticPriceProvider {
+	provider := &StaticPriceProvider{
+		path:   path,
+		prices: make(map[string]float64),
+	}
+
+	if err := provider.reloadIfChanged(); err != nil {
+		log.Fatal().Err(err).Str("path", path).Msg("Could not load prices file")
+	}
+
+	return provider
+}
+
+func (p *StaticPriceProvider) Name() string {
+	return "static"
+}
+
+func (p *StaticPriceProvider) GetPrice(denom string) (float64, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// the old prices are better than nothing if the file is being rewritten right now
+	if err := p.reloadIfChanged(); err != nil {
+		log.Warn().Err(err).Str("path", p.path).Msg("Could not reload prices file")
+	}
+
+	price, found := p.prices[getPriceKey(denom)]
+	if !found {
+		return 0, fmt.Errorf("no price for denom %s in %s", denom, p.path)
+	}
+
+	return price, nil
+}
+
+func (p *StaticPriceProvider) reloadIfChanged() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return err
+	}
+
+	if info.ModTime().Equal(p.lastModTime) {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return err
+	}
+
+	var prices map[string]float64
+	if err := json.Unmarshal(content, &prices); err != nil {
+		return err
+	}
+
+	p.prices = make(map[string]float64, len(prices))
+	for denom, price := range prices {
+		p.prices[getPriceKey(denom)] = price
+	}
+	p.lastModTime = info.ModTime()
+
+	log.Debug().Str("path", p.path).Int("count", len(prices)).Msg("Loaded prices file")
+	return nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StdoutReporter prints reports instead of sending them somewhere,
+// which is useful to check the queries and formatting without spamming a real chat.
+type StdoutReporter struct {
+	ReporterName    string
+	ReporterQueries []string
+	ReporterFilter  *Filter
+
+	StdoutEnabled bool
+	StdoutFormat  string
+
+	StdoutFailedTxsMode FailedTxsMode
+	StdoutTxFields      TxFields
+
+	StdoutSerializer Serializer
+}
+
+func (r StdoutReporter) Serialize(report Report) string {
+	var sb strings.Builder
+
+	serializer := r.Serializer().withChain(report.Chain)
+
+	sb.WriteString(report.Tx.Serialize(serializer) + "\n\n")
+
+	for _, msg := range report.Msgs {
+		sb.WriteString(msg.Serialize(serializer) + "\n\n")
+	}
+
+	return sb.String()
+}
+
+func (r *StdoutReporter) Init() {
+	if !r.StdoutEnabled {
+		log.Debug().Msg("Stdout reporter is not enabled, not creating it.")
+		return
+	}
+
+	switch r.StdoutFormat {
+	case "plain":
+		r.StdoutSerializer = NewPlainSerializer(r.StdoutTxFields)
+	case "html":
+		r.StdoutSerializer = NewHtmlSerializer(r.StdoutTxFields)
+	case "markdown":
+		r.StdoutSerializer = NewMarkdownSerializer(r.StdoutTxFields)
+	default:
+		log.Fatal().
+			Str("format", r.StdoutFormat).
+			Msg("Unsupported stdout format, expected one of: plain, html, markdown")
+	}
+}
+
+func (r StdoutReporter) Enabled() bool {
+	return r.StdoutEnabled
+}
+
+func (r StdoutReporter) Serializer() Serializer {
+	return r.StdoutSerializer
+}
+
+func (r StdoutReporter) SendReport(report Report) error {
+	_, err := fmt.Println(r.Serialize(report))
+	return err
+}
+
+func (r StdoutReporter) Name() string {
+	return r.ReporterName
+}
+
+func (r StdoutReporter) FailedTxsMode() FailedTxsMode {
+	return r.StdoutFailedTxsMode
+}
+
+func (r StdoutReporter) Queries() []string {
+	return r.ReporterQueries
+}
+
+func (r StdoutReporter) Filter() *Filter {
+	return r.ReporterFilter
+}
@@ -3,31 +3,55 @@ package main
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	telegramBot "gopkg.in/tucnak/telebot.v2"
 )
 
 type TelegramReporter struct {
-	TelegramToken string
-	TelegramChat  int
+	ReporterName    string
+	ReporterQueries []string
+	ReporterFilter  *Filter
+
+	TelegramToken  string
+	TelegramChat   int
+	TelegramAdmins []int
 
 	TelegramSetAliasCommand    string
 	TelegramClearAliasCommand  string
 	TelegramListAliasesCommand string
+	TelegramListQueriesCommand string
+	TelegramAddQueryCommand    string
+	TelegramRemoveQueryCommand string
+
+	TelegramFailedTxsMode FailedTxsMode
+	TelegramTxFields      TxFields
 
 	TelegramBot    *telegramBot.Bot
 	HtmlSerializer Serializer
-	CacheManager   *CacheManager
+
+	// the chats and admins of all the reporters with the same bot, the commands are accepted from them
+	commandChats  []int64
+	commandAdmins []int
 }
 
+// telegramBots are the reporters handling the commands for each token. The bot is shared
+// by the reporters with the same token, as only one of them can get the updates.
+var (
+	telegramBots      = make(map[string]*TelegramReporter)
+	telegramBotsMutex sync.Mutex
+)
+
 func (r TelegramReporter) Serialize(report Report) string {
 	var sb strings.Builder
 
-	sb.WriteString(report.Tx.Serialize(r.Serializer()) + "\n\n")
+	serializer := r.Serializer().withChain(report.Chain)
+
+	sb.WriteString(report.Tx.Serialize(serializer) + "\n\n")
 
 	for _, msg := range report.Msgs {
-		sb.WriteString(msg.Serialize(r.Serializer()) + "\n\n")
+		sb.WriteString(msg.Serialize(serializer) + "\n\n")
 	}
 
 	return sb.String()
@@ -39,8 +63,23 @@ func (r *TelegramReporter) Init() {
 		return
 	}
 
+	r.HtmlSerializer = NewHtmlSerializer(r.TelegramTxFields)
+
+	telegramBotsMutex.Lock()
+	defer telegramBotsMutex.Unlock()
+
+	if handler, found := telegramBots[r.TelegramToken]; found {
+		log.Debug().
+			Str("reporter", r.ReporterName).
+			Msg("Telegram bot is already used by another reporter, only sending reports to this chat")
+		r.TelegramBot = handler.TelegramBot
+		handler.commandChats = append(handler.commandChats, int64(r.TelegramChat))
+		handler.commandAdmins = append(handler.commandAdmins, r.TelegramAdmins...)
+		return
+	}
+
 	bot, err := telegramBot.NewBot(telegramBot.Settings{
-		Token:  TelegramToken,
+		Token:  r.TelegramToken,
 		Poller: &telegramBot.LongPoller{Timeout: 10 * time.Second},
 	})
 
@@ -50,44 +89,75 @@ func (r *TelegramReporter) Init() {
 	}
 
 	r.TelegramBot = bot
-	r.HtmlSerializer = Serializer{
-		LinksSerializer: func(address string, text string) string {
-			return fmt.Sprintf(`<a href="%s">%s</a>`, address, text)
-		},
-		StrongSerializer: func(text string) string {
-			return fmt.Sprintf(`<strong>%s</strong>`, text)
-		},
-		CodeSerializer: func(text string) string {
-			return fmt.Sprintf(`<code>%s</code>`, text)
-		},
-		MultilineCodeSerializer: func(text string) string {
-			return fmt.Sprintf(`<pre>%s</pre>`, text)
-		},
-		CacheManager: r.CacheManager,
+	r.commandChats = []int64{int64(r.TelegramChat)}
+	r.commandAdmins = append([]int{}, r.TelegramAdmins...)
+	telegramBots[r.TelegramToken] = r
+
+	r.TelegramBot.Handle(r.TelegramSetAliasCommand, r.onlyAllowed(r.processSetAliasCommand))
+	r.TelegramBot.Handle(r.TelegramClearAliasCommand, r.onlyAllowed(r.processClearAliasCommand))
+	r.TelegramBot.Handle(r.TelegramListAliasesCommand, r.onlyAllowed(r.processListAliasesCommand))
+	r.TelegramBot.Handle(r.TelegramListQueriesCommand, r.onlyAllowed(r.processListQueriesCommand))
+	r.TelegramBot.Handle(r.TelegramAddQueryCommand, r.onlyAllowed(r.processAddQueryCommand))
+	r.TelegramBot.Handle(r.TelegramRemoveQueryCommand, r.onlyAllowed(r.processRemoveQueryCommand))
+	go r.TelegramBot.Start()
+}
+
+// onlyAllowed skips the commands that are not sent to the reports chats or by the admins,
+// as anyone can find the bot and message it, and the commands change what is reported.
+func (reporter *TelegramReporter) onlyAllowed(handler func(*telegramBot.Message)) func(*telegramBot.Message) {
+	return func(message *telegramBot.Message) {
+		if !reporter.isAllowed(message) {
+			log.Warn().
+				Str("text", message.Text).
+				Int64("chat", message.Chat.ID).
+				Msg("Received command from a chat or user that is not allowed, skipping")
+			return
+		}
+
+		handler(message)
 	}
+}
 
-	r.TelegramBot.Handle(r.TelegramSetAliasCommand, r.processSetAliasCommand)
-	r.TelegramBot.Handle(r.TelegramClearAliasCommand, r.processClearAliasCommand)
-	r.TelegramBot.Handle(r.TelegramListAliasesCommand, r.processListAliasesCommand)
-	go r.TelegramBot.Start()
+func (reporter *TelegramReporter) isAllowed(message *telegramBot.Message) bool {
+	for _, chat := range reporter.commandChats {
+		if message.Chat.ID == chat {
+			return true
+		}
+	}
+
+	if message.Sender == nil {
+		return false
+	}
+
+	for _, admin := range reporter.commandAdmins {
+		if message.Sender.ID == admin {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (reporter *TelegramReporter) processSetAliasCommand(message *telegramBot.Message) {
 	reporter.logQuery(message, reporter.TelegramSetAliasCommand)
 
-	text := fmt.Sprintf("Usage: `%s` &lt;wallet-address&gt; &lt;alias&gt;", reporter.TelegramSetAliasCommand)
+	text := fmt.Sprintf(
+		"Usage: `%s` %s&lt;wallet-address&gt; &lt;alias&gt;",
+		reporter.TelegramSetAliasCommand,
+		getChainCommandUsage(),
+	)
 
-	args := strings.SplitN(message.Text, " ", 3)
+	chain, args := reporter.getChainAndArgs(message, 2)
 
-	if len(args) > 2 {
-		labelsConfigManager.setWalletLabel(args[1], args[2])
+	if chain != nil && len(args) >= 2 {
+		chain.LabelsConfigManager.setWalletLabel(args[0], args[1])
 		text = fmt.Sprintf(
 			"Successfully set alias for %s: %s",
-			reporter.HtmlSerializer.LinksSerializer(makeMintscanAccountLink(args[1]), args[1]),
-			reporter.HtmlSerializer.CodeSerializer(args[2]),
+			reporter.HtmlSerializer.LinksSerializer(chain.makeMintscanAccountLink(args[0]), args[0]),
+			reporter.HtmlSerializer.CodeSerializer(args[1]),
 		)
 	} else {
-		log.Info().Msg("/set-alias: args length <= 2")
+		log.Info().Msg("/set-alias: args length < 2 or chain not found")
 	}
 
 	if err := reporter.sendMessage(message, text); err != nil {
@@ -98,18 +168,22 @@ func (reporter *TelegramReporter) processSetAliasCommand(message *telegramBot.Me
 func (reporter *TelegramReporter) processClearAliasCommand(message *telegramBot.Message) {
 	reporter.logQuery(message, reporter.TelegramClearAliasCommand)
 
-	text := fmt.Sprintf("Usage: `%s` &lt;wallet-address&gt;", reporter.TelegramClearAliasCommand)
+	text := fmt.Sprintf(
+		"Usage: `%s` %s&lt;wallet-address&gt;",
+		reporter.TelegramClearAliasCommand,
+		getChainCommandUsage(),
+	)
 
-	args := strings.SplitN(message.Text, " ", 2)
+	chain, args := reporter.getChainAndArgs(message, 1)
 
-	if len(args) >= 2 {
-		labelsConfigManager.clearWalletLabel(args[1])
+	if chain != nil && len(args) >= 1 {
+		chain.LabelsConfigManager.clearWalletLabel(args[0])
 		text = fmt.Sprintf(
 			"Successfully cleared alias for %s",
-			reporter.HtmlSerializer.LinksSerializer(makeMintscanAccountLink(args[1]), args[1]),
+			reporter.HtmlSerializer.LinksSerializer(chain.makeMintscanAccountLink(args[0]), args[0]),
 		)
 	} else {
-		log.Info().Msg("/clear-alias: args length < 2")
+		log.Info().Msg("/clear-alias: args length < 1 or chain not found")
 	}
 
 	if err := reporter.sendMessage(message, text); err != nil {
@@ -121,21 +195,27 @@ func (reporter *TelegramReporter) processListAliasesCommand(message *telegramBot
 	reporter.logQuery(message, reporter.TelegramListAliasesCommand)
 
 	var sb strings.Builder
-	sb.WriteString(reporter.HtmlSerializer.StrongSerializer("Wallet aliases:") + "\n")
 
-	if len(labelsConfigManager.config.WalletLabels) == 0 {
-		sb.WriteString(fmt.Sprintf(
-			"No label aliases are set. You can set one using `%s` &lt;wallet-address&gt;",
-			reporter.TelegramSetAliasCommand,
-		))
-	}
-
-	for key, value := range labelsConfigManager.config.WalletLabels {
-		sb.WriteString(fmt.Sprintf(
-			"• %s: %s\n",
-			reporter.HtmlSerializer.LinksSerializer(makeMintscanAccountLink(key), key),
-			reporter.HtmlSerializer.CodeSerializer(value),
-		))
+	for _, chain := range Chains {
+		sb.WriteString(reporter.HtmlSerializer.StrongSerializer(
+			fmt.Sprintf("Wallet aliases on %s:", chain.Name),
+		) + "\n")
+
+		if len(chain.LabelsConfigManager.config.WalletLabels) == 0 {
+			sb.WriteString(fmt.Sprintf(
+				"No label aliases are set. You can set one using `%s` %s&lt;wallet-address&gt; &lt;alias&gt;\n",
+				reporter.TelegramSetAliasCommand,
+				getChainCommandUsage(),
+			))
+		}
+
+		for key, value := range chain.LabelsConfigManager.config.WalletLabels {
+			sb.WriteString(fmt.Sprintf(
+				"• %s: %s\n",
+				reporter.HtmlSerializer.LinksSerializer(chain.makeMintscanAccountLink(key), key),
+				reporter.HtmlSerializer.CodeSerializer(value),
+			))
+		}
 	}
 
 	if err := reporter.sendMessage(message, sb.String()); err != nil {
@@ -143,11 +223,63 @@ func (reporter *TelegramReporter) processListAliasesCommand(message *telegramBot
 	}
 }
 
+func (reporter *TelegramReporter) processListQueriesCommand(message *telegramBot.Message) {
+	reporter.logQuery(message, reporter.TelegramListQueriesCommand)
+
+	text := processListQueriesCommand(reporter.HtmlSerializer)
+
+	if err := reporter.sendMessage(message, text); err != nil {
+		log.Error().Err(err).Msg("Could not send response to /list-queries command")
+	}
+}
+
+func (reporter *TelegramReporter) processAddQueryCommand(message *telegramBot.Message) {
+	reporter.logQuery(message, reporter.TelegramAddQueryCommand)
+
+	text := processAddQueryCommand(reporter.HtmlSerializer, reporter.TelegramAddQueryCommand, message.Payload)
+
+	if err := reporter.sendMessage(message, text); err != nil {
+		log.Error().Err(err).Msg("Could not send response to /add-query command")
+	}
+}
+
+func (reporter *TelegramReporter) processRemoveQueryCommand(message *telegramBot.Message) {
+	reporter.logQuery(message, reporter.TelegramRemoveQueryCommand)
+
+	text := processRemoveQueryCommand(reporter.HtmlSerializer, reporter.TelegramRemoveQueryCommand, message.Payload)
+
+	if err := reporter.sendMessage(message, text); err != nil {
+		log.Error().Err(err).Msg("Could not send response to /remove-query command")
+	}
+}
+
+// getChainAndArgs strips the command itself from the message text, resolves the chain
+// and splits the rest into at most argsCount arguments.
+func (reporter *TelegramReporter) getChainAndArgs(message *telegramBot.Message, argsCount int) (*Chain, []string) {
+	commandAndText := strings.SplitN(message.Text, " ", 2)
+	if len(commandAndText) < 2 {
+		return nil, []string{}
+	}
+
+	chain, text, found := findChainForCommand(commandAndText[1])
+	if !found || text == "" {
+		return nil, []string{}
+	}
+
+	return chain, strings.SplitN(text, " ", argsCount)
+}
+
 func (reporter *TelegramReporter) logQuery(message *telegramBot.Message, command string) {
+	// channel posts have no sender
+	user := ""
+	if message.Sender != nil {
+		user = message.Sender.Username
+	}
+
 	log.Info().
 		Str("command", command).
 		Str("text", message.Text).
-		Str("user", message.Sender.Username).
+		Str("user", user).
 		Msg("Received command")
 }
 
@@ -186,5 +318,17 @@ func (r TelegramReporter) SendReport(report Report) error {
 }
 
 func (r TelegramReporter) Name() string {
-	return "TelegramReporter"
+	return r.ReporterName
+}
+
+func (r TelegramReporter) FailedTxsMode() FailedTxsMode {
+	return r.TelegramFailedTxsMode
+}
+
+func (r TelegramReporter) Queries() []string {
+	return r.ReporterQueries
+}
+
+func (r TelegramReporter) Filter() *Filter {
+	return r.ReporterFilter
 }
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	ibctypes "github.com/cosmos/ibc-go/modules/apps/transfer/types"
+)
+
+// AssetList is the chain-registry assetlist.json format, only the fields we need.
+type AssetList struct {
+	ChainName string  `json:"chain_name"`
+	Assets    []Asset `json:"assets"`
+}
+
+type Asset struct {
+	Base       string           `json:"base"`
+	Display    string           `json:"display"`
+	Symbol     string           `json:"symbol"`
+	DenomUnits []AssetDenomUnit `json:"denom_units"`
+}
+
+type AssetDenomUnit struct {
+	Denom    string   `json:"denom"`
+	Exponent uint32   `json:"exponent"`
+	Aliases  []string `json:"aliases"`
+}
+
+type TokenInfo struct {
+	Symbol   string
+	Exponent uint32
+}
+
+// TokenRegistry knows the symbols and exponents of the tokens, by their base denom,
+// and the IBC denom traces, which are stored in the state, as they never change.
+type TokenRegistry struct {
+	chain       string
+	tokens      map[string]TokenInfo
+	grpcWrapper *GrpcWrapper
+}
+
+func NewTokenRegistry(chain string, assetLists []string, grpcWrapper *GrpcWrapper) *TokenRegistry {
+	registry := &TokenRegistry{
+		chain:       chain,
+		tokens:      make(map[string]TokenInfo),
+		grpcWrapper: grpcWrapper,
+	}
+
+	for _, path := range assetLists {
+		if err := registry.loadAssetList(path); err != nil {
+			log.Fatal().Err(err).Str("chain", chain).Str("path", path).Msg("Could not load assetlist")
+		}
+	}
+
+	return registry
+}
+
+func (r *TokenRegistry) loadAssetList(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var assetList AssetList
+	if err := json.Unmarshal(content, &assetList); err != nil {
+		return err
+	}
+
+	for _, asset := range assetList.Assets {
+		if token, found := getAssetToken(asset); found {
+			r.tokens[asset.Base] = token
+		}
+	}
+
+	log.Info().
+		Str("chain", r.chain).
+		Str("path", path).
+		Str("assetlist", assetList.ChainName).
+		Int("assets", len(assetList.Assets)).
+		Msg("Loaded assetlist")
+
+	return nil
+}
+
+// getAssetToken returns the symbol and the exponent of the asset display unit.
+func getAssetToken(asset Asset) (TokenInfo, bool) {
+	symbol := asset.Symbol
+	if symbol == "" {
+		symbol = asset.Display
+	}
+
+	for _, unit := range asset.DenomUnits {
+		if unit.Denom == asset.Display || contains(unit.Aliases, asset.Display) {
+			return TokenInfo{Symbol: symbol, Exponent: unit.Exponent}, true
+		}
+	}
+
+	return TokenInfo{}, false
+}
+
+func (r *TokenRegistry) getToken(baseDenom string) (TokenInfo, bool) {
+	token, found := r.tokens[baseDenom]
+	return token, found
+}
+
+// getDenomTrace returns the denom trace of an IBC token from the state, or queries the node for it.
+func (r *TokenRegistry) getDenomTrace(denom string) (ibctypes.DenomTrace, error) {
+	hash := strings.TrimPrefix(denom, "ibc/")
+
+	if trace, found := stateManager.getDenomTrace(r.chain, hash); found {
+		return trace, nil
+	}
+
+	trace, err := r.grpcWrapper.getDenomTrace(hash)
+	if err != nil {
+		return ibctypes.DenomTrace{}, err
+	}
+
+	stateManager.setDenomTrace(r.chain, hash, trace)
+	return trace, nil
+}
+
+// getTraceChannel returns the channel the IBC token came to this chain through,
+// which is the first one in its path.
+func getTraceChannel(trace ibctypes.DenomTrace) string {
+	parts := strings.Split(trace.Path, "/")
+	if len(parts) < 2 {
+		return ""
+	}
+
+	return parts[1]
+}
@@ -4,7 +4,13 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	codecTypes "github.com/cosmos/cosmos-sdk/codec/types"
+	cryptoCodec "github.com/cosmos/cosmos-sdk/crypto/codec"
+	cryptoTypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
 	"github.com/cosmos/cosmos-sdk/types/tx"
 	"github.com/gogo/protobuf/proto"
 	"github.com/tendermint/tendermint/crypto/tmhash"
@@ -16,17 +22,131 @@ type Tx struct {
 	Hash   string
 	Height int64
 	Memo   string
+
+	Code      uint32
+	Codespace string
+	Log       string
+
+	Fee        []Coin
+	FeePayer   string
+	FeeGranter string
+	GasUsed    int64
+	GasWanted  int64
+	Signers    []string
+	Timestamp  time.Time
+
+	// names of the named queries the tx matched
+	MatchedQueries []string
+}
+
+// TxFields controls which optional tx details a reporter shows.
+type TxFields struct {
+	Fee     bool
+	Gas     bool
+	Signers bool
+	Time    bool
+}
+
+func parseTxFields(fields []string) TxFields {
+	var txFields TxFields
+
+	for _, field := range fields {
+		switch field {
+		case "fee":
+			txFields.Fee = true
+		case "gas":
+			txFields.Gas = true
+		case "signers":
+			txFields.Signers = true
+		case "time":
+			txFields.Time = true
+		default:
+			log.Fatal().
+				Str("field", field).
+				Msg("Unsupported tx field, expected one of: fee, gas, signers, time")
+		}
+	}
+
+	return txFields
+}
+
+func (tx Tx) Failed() bool {
+	return tx.Code != 0
+}
+
+// FailedTxsMode controls whether a reporter sends failed transactions.
+type FailedTxsMode string
+
+const (
+	FailedTxsModeReport FailedTxsMode = "report" // send all txs, failed ones are marked as failed
+	FailedTxsModeSkip   FailedTxsMode = "skip"   // send only successful txs
+	FailedTxsModeOnly   FailedTxsMode = "only"   // send only failed txs
+)
+
+func parseFailedTxsMode(mode string) FailedTxsMode {
+	switch FailedTxsMode(mode) {
+	case FailedTxsModeReport, FailedTxsModeSkip, FailedTxsModeOnly:
+		return FailedTxsMode(mode)
+	default:
+		log.Fatal().
+			Str("mode", mode).
+			Msg("Unsupported failed txs mode, expected one of: report, skip, only")
+		return ""
+	}
+}
+
+func (mode FailedTxsMode) ShouldReport(tx Tx) bool {
+	switch mode {
+	case FailedTxsModeSkip:
+		return !tx.Failed()
+	case FailedTxsModeOnly:
+		return tx.Failed()
+	default:
+		return true
+	}
 }
 
 func (tx Tx) Serialize(serializer Serializer) string {
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf(
-		"Tx %s at block %s",
-		serializer.LinksSerializer(makeMintscanTxLink(tx.Hash), tx.Hash[0:8]),
-		serializer.LinksSerializer(makeMintscanBlockLink(tx.Height), strconv.FormatInt(tx.Height, 10)),
+		"Tx %s at block %s on %s",
+		serializer.LinksSerializer(serializer.Chain.makeMintscanTxLink(tx.Hash), tx.Hash[0:8]),
+		serializer.LinksSerializer(serializer.Chain.makeMintscanBlockLink(tx.Height), strconv.FormatInt(tx.Height, 10)),
+		serializer.StrongSerializer(serializer.Chain.Name),
 	))
 
+	if tx.Failed() {
+		sb.WriteString(fmt.Sprintf(
+			"\n%s (code %d, codespace %s)",
+			serializer.StrongSerializer("❌ Transaction failed"),
+			tx.Code,
+			serializer.CodeSerializer(tx.Codespace),
+		))
+
+		if tx.Log != "" {
+			sb.WriteString(fmt.Sprintf(
+				"\n%s %s",
+				serializer.StrongSerializer("Error:"),
+				serializer.getSingleOrMultilineCodeBlock(truncateString(tx.Log, MaxGenericMsgBodyLength)),
+			))
+		}
+	}
+
+	if len(tx.MatchedQueries) > 0 {
+		// the query names can be added with commands, so they can have anything in them
+		queries := make([]string, len(tx.MatchedQueries))
+		for index, query := range tx.MatchedQueries {
+			queries[index] = serializer.CodeSerializer(query)
+		}
+
+		sb.WriteString(fmt.Sprintf(
+			"\n%s %s",
+			serializer.StrongSerializer("Matched:"),
+			strings.Join(queries, ", "),
+		))
+	}
+
 	if tx.Memo != "" {
 		sb.WriteString(fmt.Sprintf(
 			"\n%s %s",
@@ -35,10 +155,60 @@ func (tx Tx) Serialize(serializer Serializer) string {
 		))
 	}
 
+	if serializer.TxFields.Time && !tx.Timestamp.IsZero() {
+		sb.WriteString(fmt.Sprintf(
+			"\n%s %s",
+			serializer.StrongSerializer("Time:"),
+			tx.Timestamp.UTC().Format("2006-01-02 15:04:05 MST"),
+		))
+	}
+
+	if serializer.TxFields.Fee && len(tx.Fee) > 0 {
+		sb.WriteString("\n" + serializer.StrongSerializer("Fee:"))
+
+		for _, coin := range tx.Fee {
+			sb.WriteString(" " + serializer.getCoinMaybeWithFiatPrice(coin))
+		}
+
+		if tx.FeePayer != "" {
+			sb.WriteString(fmt.Sprintf(
+				"\n%s %s",
+				serializer.StrongSerializer("Fee payer:"),
+				serializer.getWalletWithLabel(tx.FeePayer),
+			))
+		}
+
+		if tx.FeeGranter != "" {
+			sb.WriteString(fmt.Sprintf(
+				"\n%s %s",
+				serializer.StrongSerializer("Fee granter:"),
+				serializer.getWalletWithLabel(tx.FeeGranter),
+			))
+		}
+	}
+
+	if serializer.TxFields.Gas && tx.GasWanted != 0 {
+		sb.WriteString(fmt.Sprintf(
+			"\n%s %s",
+			serializer.StrongSerializer("Gas used:"),
+			serializer.CodeSerializer(Printer.Sprintf("%d / %d", tx.GasUsed, tx.GasWanted)),
+		))
+	}
+
+	if serializer.TxFields.Signers {
+		for _, signer := range tx.Signers {
+			sb.WriteString(fmt.Sprintf(
+				"\n%s %s",
+				serializer.StrongSerializer("Signer:"),
+				serializer.getWalletWithLabel(signer),
+			))
+		}
+	}
+
 	return sb.String()
 }
 
-func parseTx(txResult abciTypes.TxResult) Tx {
+func (c *Chain) parseTx(txResult abciTypes.TxResult) Tx {
 	Hash := fmt.Sprintf("%X", tmhash.Sum(txResult.Tx))
 	Height := txResult.Height
 
@@ -49,9 +219,104 @@ func parseTx(txResult abciTypes.TxResult) Tx {
 		return Tx{}
 	}
 
+	fee := []Coin{}
+	for _, coin := range tx.GetAuthInfo().GetFee().GetAmount() {
+		fee = append(fee, c.getCoin(coin))
+	}
+
+	timestamp, err := c.CacheManager.getBlockTimeMaybeFromCache(Height)
+	if err != nil {
+		log.Warn().Err(err).Int64("height", Height).Msg("Could not get block time")
+	}
+
 	return Tx{
-		Hash:   Hash,
-		Height: Height,
-		Memo:   tx.GetBody().GetMemo(),
+		Hash:       Hash,
+		Height:     Height,
+		Memo:       tx.GetBody().GetMemo(),
+		Code:       txResult.Result.Code,
+		Codespace:  txResult.Result.Codespace,
+		Log:        txResult.Result.Log,
+		Fee:        fee,
+		FeePayer:   tx.GetAuthInfo().GetFee().GetPayer(),
+		FeeGranter: tx.GetAuthInfo().GetFee().GetGranter(),
+		GasUsed:    txResult.Result.GasUsed,
+		GasWanted:  txResult.Result.GasWanted,
+		Signers:    c.getTxSigners(tx, txResult.Result.Events),
+		Timestamp:  timestamp,
+	}
+}
+
+// getTxSigners takes signers from the events emitted by the ante handler. The failed txs
+// have no such events, so for them the addresses are built from the signers' public keys.
+func (c *Chain) getTxSigners(tx tx.Tx, events []abciTypes.Event) []string {
+	if signers := getEventsSigners(events); len(signers) > 0 {
+		return signers
+	}
+
+	return getPubKeysSigners(tx.GetAuthInfo().GetSignerInfos(), c.accountPrefix)
+}
+
+// getEventsSigners takes signers from the tx events, which are formatted like
+// "<address>/<sequence>". The addresses there already have the chain's bech32 prefix.
+func getEventsSigners(events []abciTypes.Event) []string {
+	signers := []string{}
+
+	for _, event := range events {
+		if event.Type != sdk.EventTypeTx {
+			continue
+		}
+
+		for _, attribute := range event.Attributes {
+			if string(attribute.Key) != sdk.AttributeKeyAccountSequence {
+				continue
+			}
+
+			signer := strings.SplitN(string(attribute.Value), "/", 2)[0]
+			signers = append(signers, signer)
+		}
 	}
+
+	return signers
+}
+
+// pubKeysRegistry knows all the public key types of the SDK, to get the signers from them.
+var pubKeysRegistry = newPubKeysRegistry()
+
+func newPubKeysRegistry() codecTypes.InterfaceRegistry {
+	registry := codecTypes.NewInterfaceRegistry()
+	cryptoCodec.RegisterInterfaces(registry)
+	return registry
+}
+
+// getPubKeysSigners builds the signers addresses from their public keys. The keys can be
+// omitted for the accounts which have them on chain already, and the chains can have their
+// own key types, so such signers are skipped.
+func getPubKeysSigners(signerInfos []*tx.SignerInfo, prefix string) []string {
+	signers := []string{}
+
+	if prefix == "" {
+		return signers
+	}
+
+	for _, signerInfo := range signerInfos {
+		if signerInfo.PublicKey == nil {
+			continue
+		}
+
+		var pubKey cryptoTypes.PubKey
+		if err := pubKeysRegistry.UnpackAny(signerInfo.PublicKey, &pubKey); err != nil {
+			log.Warn().Err(err).Str("type", signerInfo.PublicKey.TypeUrl).Msg("Could not parse signer public key")
+			continue
+		}
+
+		signer, err := bech32.ConvertAndEncode(prefix, pubKey.Address())
+		if err != nil {
+			log.Warn().Err(err).Msg("Could not encode signer address")
+			continue
+		}
+
+		signers = append(signers, signer)
+	}
+
+	return signers
 }
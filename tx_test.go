@@ -0,0 +1,161 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	codecTypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/ed25519"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/secp256k1"
+	cryptoTypes "github.com/cosmos/cosmos-sdk/crypto/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/cosmos/cosmos-sdk/types/tx"
+	abciTypes "github.com/tendermint/tendermint/abci/types"
+)
+
+func getTxTestSignerInfo(t *testing.T, pubKey cryptoTypes.PubKey) *tx.SignerInfo {
+	packed, err := codecTypes.NewAnyWithValue(pubKey)
+	if err != nil {
+		t.Fatalf("could not pack public key: %s", err)
+	}
+
+	return &tx.SignerInfo{PublicKey: packed}
+}
+
+func getTxTestAddress(t *testing.T, prefix string, pubKey cryptoTypes.PubKey) string {
+	address, err := bech32.ConvertAndEncode(prefix, pubKey.Address())
+	if err != nil {
+		t.Fatalf("could not encode address: %s", err)
+	}
+
+	return address
+}
+
+func getTxTestEvent(eventType string, key string, value string) abciTypes.Event {
+	return abciTypes.Event{
+		Type:       eventType,
+		Attributes: []abciTypes.EventAttribute{{Key: []byte(key), Value: []byte(value)}},
+	}
+}
+
+func TestGetEventsSigners(t *testing.T) {
+	tests := []struct {
+		name     string
+		events   []abciTypes.Event
+		expected []string
+	}{
+		{"no events", []abciTypes.Event{}, []string{}},
+		{
+			"single signer",
+			[]abciTypes.Event{getTxTestEvent(sdk.EventTypeTx, sdk.AttributeKeyAccountSequence, "cosmos1signer/5")},
+			[]string{"cosmos1signer"},
+		},
+		{
+			"multiple signers",
+			[]abciTypes.Event{
+				getTxTestEvent(sdk.EventTypeTx, sdk.AttributeKeyAccountSequence, "cosmos1first/5"),
+				getTxTestEvent(sdk.EventTypeTx, sdk.AttributeKeyAccountSequence, "cosmos1second/0"),
+			},
+			[]string{"cosmos1first", "cosmos1second"},
+		},
+		{
+			"other attributes and events",
+			[]abciTypes.Event{
+				getTxTestEvent(sdk.EventTypeTx, sdk.AttributeKeyFee, "100uatom"),
+				getTxTestEvent("transfer", sdk.AttributeKeyAccountSequence, "cosmos1other/1"),
+				getTxTestEvent(sdk.EventTypeTx, sdk.AttributeKeyAccountSequence, "cosmos1signer/5"),
+			},
+			[]string{"cosmos1signer"},
+		},
+	}
+
+	for _, test := range tests {
+		result := getEventsSigners(test.events)
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestGetPubKeysSigners(t *testing.T) {
+	first := secp256k1.GenPrivKey().PubKey()
+	second := ed25519.GenPrivKey().PubKey()
+
+	tests := []struct {
+		name        string
+		signerInfos []*tx.SignerInfo
+		prefix      string
+		expected    []string
+	}{
+		{"no signers", []*tx.SignerInfo{}, "cosmos", []string{}},
+		{
+			"secp256k1",
+			[]*tx.SignerInfo{getTxTestSignerInfo(t, first)},
+			"cosmos",
+			[]string{getTxTestAddress(t, "cosmos", first)},
+		},
+		{
+			"other prefix",
+			[]*tx.SignerInfo{getTxTestSignerInfo(t, first)},
+			"osmo",
+			[]string{getTxTestAddress(t, "osmo", first)},
+		},
+		{
+			"multiple signers",
+			[]*tx.SignerInfo{getTxTestSignerInfo(t, first), getTxTestSignerInfo(t, second)},
+			"cosmos",
+			[]string{getTxTestAddress(t, "cosmos", first), getTxTestAddress(t, "cosmos", second)},
+		},
+		{
+			// the key is not in the tx if the account has it on chain already
+			"no public key",
+			[]*tx.SignerInfo{{}, getTxTestSignerInfo(t, first)},
+			"cosmos",
+			[]string{getTxTestAddress(t, "cosmos", first)},
+		},
+		{
+			"unknown key type",
+			[]*tx.SignerInfo{{PublicKey: &codecTypes.Any{TypeUrl: "/ethermint.crypto.v1.ethsecp256k1.PubKey"}}},
+			"cosmos",
+			[]string{},
+		},
+		{
+			"prefix unknown",
+			[]*tx.SignerInfo{getTxTestSignerInfo(t, first)},
+			"",
+			[]string{},
+		},
+	}
+
+	for _, test := range tests {
+		result := getPubKeysSigners(test.signerInfos, test.prefix)
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestGetTxSigners(t *testing.T) {
+	pubKey := secp256k1.GenPrivKey().PubKey()
+	chain := &Chain{ChainConfig: ChainConfig{Name: "cosmos"}, accountPrefix: "cosmos"}
+
+	parsedTx := tx.Tx{AuthInfo: &tx.AuthInfo{SignerInfos: []*tx.SignerInfo{getTxTestSignerInfo(t, pubKey)}}}
+	events := []abciTypes.Event{getTxTestEvent(sdk.EventTypeTx, sdk.AttributeKeyAccountSequence, "cosmos1signer/5")}
+
+	tests := []struct {
+		name     string
+		events   []abciTypes.Event
+		expected []string
+	}{
+		{"from events", events, []string{"cosmos1signer"}},
+		{"failed tx without events", []abciTypes.Event{}, []string{getTxTestAddress(t, "cosmos", pubKey)}},
+	}
+
+	for _, test := range tests {
+		result := chain.getTxSigners(parsedTx, test.events)
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, result)
+		}
+	}
+}
@@ -2,32 +2,74 @@ package main
 
 import (
 	"fmt"
-	"strconv"
+	"reflect"
 	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
 type Msg interface {
 	Serialize(Serializer Serializer) string
+	SerializeJSON(chain *Chain) interface{}
+	Summary(chain *Chain) string
 	Empty() bool
 }
 
+// getMsgTypeName returns the message type, like MsgSend.
+func getMsgTypeName(msg Msg) string {
+	if genericMsg, ok := msg.(MsgGeneric); ok {
+		return genericMsg.getTypeName()
+	}
+
+	return reflect.TypeOf(msg).Name()
+}
+
 type Serializer struct {
 	LinksSerializer         func(string, string) string
 	StrongSerializer        func(string) string
 	CodeSerializer          func(string) string
 	MultilineCodeSerializer func(string) string
-	CacheManager            *CacheManager
+	TxFields                TxFields
+	Chain                   *Chain
 }
 
 type Report struct {
-	Tx   Tx
-	Msgs []Msg
+	Chain *Chain
+	Tx    Tx
+	Msgs  []Msg
 }
 
 func (r Report) Empty() bool {
 	return r.Tx.Hash == "" || len(r.Msgs) == 0
 }
 
+// MaxReportSummaryMsgs is how many messages are listed in the summary,
+// the rest are shown as "and N more".
+const MaxReportSummaryMsgs = 2
+
+// Summary returns a one-line description of the report, like
+// "Delegate 1,200 ATOM to SOLAR", to be used in email subjects and alerts.
+func (r Report) Summary() string {
+	summaries := []string{}
+
+	for index, msg := range r.Msgs {
+		if index >= MaxReportSummaryMsgs {
+			summaries = append(summaries, fmt.Sprintf("and %d more", len(r.Msgs)-MaxReportSummaryMsgs))
+			break
+		}
+
+		summaries = append(summaries, msg.Summary(r.Chain))
+	}
+
+	summary := strings.Join(summaries, ", ")
+
+	if r.Tx.Failed() {
+		summary = "Failed: " + summary
+	}
+
+	return fmt.Sprintf("[%s] %s", r.Chain.Name, summary)
+}
+
 type Reporter interface {
 	Serialize(Report) string
 	Init()
@@ -35,14 +77,24 @@ type Reporter interface {
 	SendReport(Report) error
 	Name() string
 	Serializer() Serializer
+	FailedTxsMode() FailedTxsMode
+	Queries() []string
+	Filter() *Filter
+}
+
+// withChain returns a copy of the serializer bound to a chain, so links, labels
+// and denoms are taken from the chain the report is about.
+func (s Serializer) withChain(chain *Chain) Serializer {
+	s.Chain = chain
+	return s
 }
 
 func (s Serializer) getWalletWithLabel(address string) string {
-	label, labelFound := labelsConfigManager.getWalletLabel(address)
+	label, labelFound := s.Chain.LabelsConfigManager.getWalletLabel(address)
 
 	var sb strings.Builder
 
-	sb.WriteString(s.LinksSerializer(makeMintscanAccountLink(address), address))
+	sb.WriteString(s.LinksSerializer(s.Chain.makeMintscanAccountLink(address), address))
 
 	if labelFound {
 		sb.WriteString(fmt.Sprintf(" (%s)", s.CodeSerializer(label)))
@@ -62,9 +114,9 @@ func (s Serializer) getSingleOrMultilineCodeBlock(block string) string {
 func (s Serializer) getValidatorWithName(address string) string {
 	var sb strings.Builder
 
-	sb.WriteString(s.LinksSerializer(makeMintscanValidatorLink(address), address))
+	sb.WriteString(s.LinksSerializer(s.Chain.makeMintscanValidatorLink(address), address))
 
-	if validator, err := s.CacheManager.getValidatorMaybeFromCache(address); err != nil {
+	if validator, err := s.Chain.CacheManager.getValidatorMaybeFromCache(address); err != nil {
 		log.Warn().Err(err).Str("address", address).Msg("Could not load delegate validator info")
 	} else {
 		sb.WriteString(fmt.Sprintf(" (%s)", s.CodeSerializer(validator.Description.Moniker)))
@@ -73,47 +125,63 @@ func (s Serializer) getValidatorWithName(address string) string {
 	return sb.String()
 }
 
-func (s Serializer) getTokensMaybeWithDollarPrice(amount float64, denom string) string {
-	rate, err := s.CacheManager.getRate()
-
-	if err != nil || rate == 0 {
+// getTokensMaybeWithFiatPrice adds the fiat value of the tokens if their price is known.
+func (s Serializer) getTokensMaybeWithFiatPrice(amount sdk.Dec, denom string, baseDenom string) string {
+	price, found := s.Chain.PriceManager.getPrice(baseDenom)
+	if !found {
 		return s.getTokensFormatted(amount, denom)
 	}
 
-	return s.CodeSerializer(Printer.Sprintf(
-		"%.6f %s ($%.3f)",
-		amount,
+	return s.CodeSerializer(fmt.Sprintf(
+		"%s %s (%s)",
+		formatAmount(amount, s.Chain.DisplayPrecision),
 		denom,
-		rate*amount,
+		formatFiatValue(getFiatValue(amount, price), s.Chain.FiatCurrency),
 	))
 }
 
-func (s Serializer) getTokensFormatted(amount float64, denom string) string {
-	return s.CodeSerializer(Printer.Sprintf(
-		"%.6f %s",
-		amount,
+func (s Serializer) getCoinMaybeWithFiatPrice(coin Coin) string {
+	return s.getTokensMaybeWithFiatPrice(coin.Amount, coin.DisplayDenom(), coin.BaseDenom)
+}
+
+func (s Serializer) getTokensFormatted(amount sdk.Dec, denom string) string {
+	return s.CodeSerializer(fmt.Sprintf(
+		"%s %s",
+		formatAmount(amount, s.Chain.DisplayPrecision),
 		denom,
 	))
 }
 
+// getWalletSummary returns the wallet label if it's set, or the address otherwise.
+func (c *Chain) getWalletSummary(address string) string {
+	if label, found := c.LabelsConfigManager.getWalletLabel(address); found {
+		return label
+	}
+
+	return address
+}
+
+// getValidatorSummary returns the validator moniker, or the address if it could not be loaded.
+func (c *Chain) getValidatorSummary(address string) string {
+	if validator, err := c.CacheManager.getValidatorMaybeFromCache(address); err == nil {
+		return validator.Description.Moniker
+	}
+
+	return address
+}
+
+func getTokensSummary(amount sdk.Dec, denom string) string {
+	return fmt.Sprintf("%s %s", formatAmountShort(amount, SummaryPrecision), denom)
+}
+
 func (s Serializer) getValidatorCommissionAtBlock(address string, block int64) string {
 	var sb strings.Builder
 
-	if response, err := s.CacheManager.GrpcWrapper.getValidatorCommissionAtBlock(address, block); err != nil {
+	if response, err := s.Chain.CacheManager.GrpcWrapper.getValidatorCommissionAtBlock(address, block); err != nil {
 		log.Warn().Err(err).Str("address", address).Msg("Could not load validator commission info")
 	} else {
-		for _, coin := range response {
-			if value, err := strconv.ParseFloat(coin.Amount.String(), 64); err != nil {
-				log.Error().
-					Err(err).
-					Msg("Could not parse balance")
-			} else {
-				sb.WriteString(s.CodeSerializer(Printer.Sprintf(
-					"%.6f %s",
-					float64(value)/DenomCoefficient,
-					Denom,
-				)) + "\n")
-			}
+		for _, coin := range s.Chain.getDecCoins(response) {
+			sb.WriteString(s.getCoinMaybeWithFiatPrice(coin) + "\n")
 		}
 	}
 
@@ -123,22 +191,126 @@ func (s Serializer) getValidatorCommissionAtBlock(address string, block int64) s
 func (s Serializer) getDelegatorRewardsAtBlock(validator string, delegator string, block int64) string {
 	var sb strings.Builder
 
-	if response, err := s.CacheManager.GrpcWrapper.getDelegatorRewardsAtBlock(validator, delegator, block); err != nil {
+	if response, err := s.Chain.CacheManager.GrpcWrapper.getDelegatorRewardsAtBlock(validator, delegator, block); err != nil {
 		log.Warn().Err(err).
 			Str("validator", validator).
 			Str("delegator", delegator).
 			Msg("Could not load delegator rewards info")
 	} else {
-		for _, coin := range response {
-			if value, err := strconv.ParseFloat(coin.Amount.String(), 64); err != nil {
-				log.Error().
-					Err(err).
-					Msg("Could not parse balance")
-			} else {
-				sb.WriteString(s.getTokensMaybeWithDollarPrice(value/DenomCoefficient, Denom) + "\n")
-			}
+		for _, coin := range s.Chain.getDecCoins(response) {
+			sb.WriteString(s.getCoinMaybeWithFiatPrice(coin) + "\n")
 		}
 	}
 
 	return sb.String()
 }
+
+// getMsgAddresses returns the wallets a message is about. For the messages without
+// a dedicated parser, the common address fields are taken from the message JSON.
+func getMsgAddresses(msg Msg) []string {
+	switch msg := msg.(type) {
+	case MsgSend:
+		return []string{msg.FromAddress, msg.ToAddress}
+	case MsgDelegate:
+		return []string{msg.DelegatorAddress}
+	case MsgUndelegate:
+		return []string{msg.DelegatorAddress}
+	case MsgBeginRedelegate:
+		return []string{msg.DelegatorAddress}
+	case MsgWithdrawDelegatorReward:
+		return []string{msg.DelegatorAddress}
+	case MsgSetWithdrawAddress:
+		return []string{msg.DelegatorAddress, msg.WithdrawAddress}
+	case MsgVote:
+		return []string{msg.Voter}
+	case MsgSubmitProposal:
+		return []string{msg.Proposer}
+	case MsgIbcTransfer:
+		return []string{msg.FromAddress, msg.ToAddress}
+	case MsgIbcRecvPacket:
+		return []string{msg.Signer, msg.FromAddress, msg.ToAddress}
+	case MsgGeneric:
+		return msg.getStringFields(
+			"from_address", "to_address", "delegator_address", "sender", "receiver",
+			"granter", "grantee", "voter", "proposer", "signer", "depositor",
+		)
+	default:
+		return []string{}
+	}
+}
+
+// getMsgValidators returns the validators a message is about. For the messages without
+// a dedicated parser, like MsgUnjail, the validator is taken from the message JSON.
+func getMsgValidators(msg Msg) []string {
+	switch msg := msg.(type) {
+	case MsgDelegate:
+		return []string{msg.ValidatorAddress}
+	case MsgUndelegate:
+		return []string{msg.ValidatorAddress}
+	case MsgBeginRedelegate:
+		return []string{msg.ValidatorSrcAddress, msg.ValidatorDstAddress}
+	case MsgWithdrawDelegatorReward:
+		return []string{msg.ValidatorAddress}
+	case MsgWithdrawValidatorCommission:
+		return []string{msg.ValidatorAddress}
+	case MsgGeneric:
+		return msg.getStringFields("validator_address", "validator_addr")
+	default:
+		return []string{}
+	}
+}
+
+// getMsgNativeAmount returns the amount of native tokens in a message, in display denom.
+// It's not known for the rewards and commission withdrawals, as their amounts are not
+// in the message itself.
+func getMsgNativeAmount(msg Msg, chain *Chain) (float64, bool) {
+	switch msg := msg.(type) {
+	case MsgDelegate:
+		return getCoinNativeAmount(msg.Token, chain)
+	case MsgUndelegate:
+		return getCoinNativeAmount(msg.Token, chain)
+	case MsgBeginRedelegate:
+		return getCoinNativeAmount(msg.Token, chain)
+	case MsgSend:
+		amount := sdk.ZeroDec()
+		found := false
+
+		for _, coin := range msg.Coins {
+			if coin.BaseDenom == chain.BaseDenom {
+				amount = amount.Add(coin.Amount)
+				found = true
+			}
+		}
+
+		return amount.MustFloat64(), found
+	case MsgIbcTransfer:
+		return getCoinNativeAmount(msg.Token, chain)
+	case MsgIbcRecvPacket:
+		if msg.Token == nil {
+			return 0, false
+		}
+
+		return getCoinNativeAmount(*msg.Token, chain)
+	default:
+		// the rewards and commission are only known when the report is serialized
+		return 0, false
+	}
+}
+
+func getCoinNativeAmount(coin Coin, chain *Chain) (float64, bool) {
+	if coin.BaseDenom != chain.BaseDenom {
+		return 0, false
+	}
+
+	return coin.Amount.MustFloat64(), true
+}
+
+func contains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -4,26 +4,26 @@ import (
 	"fmt"
 )
 
-func makeMintscanLink(suffix string) string {
-	return fmt.Sprintf("https://mintscan.io/%s/%s", MintscanProject, suffix)
+func (c *Chain) makeMintscanLink(suffix string) string {
+	return fmt.Sprintf("https://mintscan.io/%s/%s", c.MintscanProject, suffix)
 }
 
-func makeMintscanTxLink(hash string) string {
-	return makeMintscanLink(fmt.Sprintf("txs/%s", hash))
+func (c *Chain) makeMintscanTxLink(hash string) string {
+	return c.makeMintscanLink(fmt.Sprintf("txs/%s", hash))
 }
 
-func makeMintscanBlockLink(block int64) string {
-	return makeMintscanLink(fmt.Sprintf("blocks/%d", block))
+func (c *Chain) makeMintscanBlockLink(block int64) string {
+	return c.makeMintscanLink(fmt.Sprintf("blocks/%d", block))
 }
 
-func makeMintscanAccountLink(account string) string {
-	return makeMintscanLink(fmt.Sprintf("account/%s", account))
+func (c *Chain) makeMintscanAccountLink(account string) string {
+	return c.makeMintscanLink(fmt.Sprintf("account/%s", account))
 }
 
-func makeMintscanValidatorLink(validator string) string {
-	return makeMintscanLink(fmt.Sprintf("validators/%s", validator))
+func (c *Chain) makeMintscanValidatorLink(validator string) string {
+	return c.makeMintscanLink(fmt.Sprintf("validators/%s", validator))
 }
 
-func makeMintscanProposalsLink() string {
-	return makeMintscanLink("validators")
+func (c *Chain) makeMintscanProposalsLink() string {
+	return c.makeMintscanLink("validators")
 }
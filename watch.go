@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+)
+
+// WatchConfig lists the addresses the bot should report the txs of, so the queries
+// for them do not have to be written by hand.
+type WatchConfig struct {
+	Validators []string `mapstructure:"validators"`
+	Wallets    []string `mapstructure:"wallets"`
+}
+
+func (w WatchConfig) Empty() bool {
+	return len(w.Validators) == 0 && len(w.Wallets) == 0
+}
+
+// the events attributes that contain a validator operator address or a wallet address
+var (
+	watchValidatorEvents = []string{
+		"delegate.validator",
+		"unbond.validator",
+		"redelegate.source_validator",
+		"redelegate.destination_validator",
+		"withdraw_rewards.validator",
+	}
+	watchWalletEvents = []string{
+		"transfer.sender",
+		"transfer.recipient",
+		"ibc_transfer.sender",
+		"fungible_token_packet.receiver",
+	}
+)
+
+// getWatchQueries returns the queries for the watched addresses. Each of them
+// is named after the address, so it's shown in notifications and can be routed by.
+func getWatchQueries(chain string, watch WatchConfig) []NamedQuery {
+	queries := []NamedQuery{}
+
+	for _, validator := range watch.Validators {
+		for _, event := range watchValidatorEvents {
+			queries = append(queries, newNamedQuery(chain, validator, fmt.Sprintf("%s = '%s'", event, validator)))
+		}
+	}
+
+	for _, wallet := range watch.Wallets {
+		for _, event := range watchWalletEvents {
+			queries = append(queries, newNamedQuery(chain, wallet, fmt.Sprintf("%s = '%s'", event, wallet)))
+		}
+	}
+
+	return queries
+}
+
+// getSubscriptions returns the queries to subscribe to. If there are more of them than
+// the node allows, it subscribes to all txs instead and they are matched locally.
+func getSubscriptions(chain string, queries []NamedQuery, maxSubscriptions int) ([]string, bool) {
+	// subscribing to the same query twice fails
+	subscriptions := []string{}
+	for _, query := range queries {
+		if !contains(subscriptions, query.Query) {
+			subscriptions = append(subscriptions, query.Query)
+		}
+	}
+
+	if maxSubscriptions > 0 && len(subscriptions) > maxSubscriptions {
+		log.Warn().
+			Str("chain", chain).
+			Int("queries", len(subscriptions)).
+			Int("max-subscriptions", maxSubscriptions).
+			Msg("Too many queries, subscribing to all txs and matching the queries locally")
+		return []string{DefaultQuery}, true
+	}
+
+	return subscriptions, false
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetSubscriptions(t *testing.T) {
+	transfers := newNamedQuery("cosmos", "transfers", "transfer.sender = 'cosmos1sender'")
+	delegations := newNamedQuery("cosmos", "delegations", "delegate.validator = 'cosmosvaloper1validator'")
+	sameAsTransfers := newNamedQuery("cosmos", "other name", "transfer.sender = 'cosmos1sender'")
+
+	tests := []struct {
+		name                  string
+		queries               []NamedQuery
+		maxSubscriptions      int
+		expectedSubscriptions []string
+		expectedMatchLocally  bool
+	}{
+		{"no queries", []NamedQuery{}, 5, []string{}, false},
+		{
+			"within limit",
+			[]NamedQuery{transfers, delegations},
+			5,
+			[]string{transfers.Query, delegations.Query},
+			false,
+		},
+		{
+			"exactly at limit",
+			[]NamedQuery{transfers, delegations},
+			2,
+			[]string{transfers.Query, delegations.Query},
+			false,
+		},
+		{
+			"over limit",
+			[]NamedQuery{transfers, delegations},
+			1,
+			[]string{DefaultQuery},
+			true,
+		},
+		{
+			"no limit",
+			[]NamedQuery{transfers, delegations},
+			0,
+			[]string{transfers.Query, delegations.Query},
+			false,
+		},
+		{
+			// the same query is subscribed to once, so it's not counted twice
+			"duplicates",
+			[]NamedQuery{transfers, sameAsTransfers, delegations},
+			2,
+			[]string{transfers.Query, delegations.Query},
+			false,
+		},
+	}
+
+	for _, test := range tests {
+		subscriptions, matchLocally := getSubscriptions("cosmos", test.queries, test.maxSubscriptions)
+
+		if !reflect.DeepEqual(subscriptions, test.expectedSubscriptions) {
+			t.Errorf("%s: expected subscriptions %v, got %v", test.name, test.expectedSubscriptions, subscriptions)
+		}
+
+		if matchLocally != test.expectedMatchLocally {
+			t.Errorf("%s: expected match locally to be %t, got %t", test.name, test.expectedMatchLocally, matchLocally)
+		}
+	}
+}
+
+func TestGetWatchQueries(t *testing.T) {
+	watch := WatchConfig{
+		Validators: []string{"cosmosvaloper1validator"},
+		Wallets:    []string{"cosmos1wallet"},
+	}
+
+	queries := getWatchQueries("cosmos", watch)
+
+	if expected := len(watchValidatorEvents) + len(watchWalletEvents); len(queries) != expected {
+		t.Fatalf("expected %d queries, got %d", expected, len(queries))
+	}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"cosmosvaloper1validator", "delegate.validator = 'cosmosvaloper1validator'"},
+		{"cosmosvaloper1validator", "redelegate.destination_validator = 'cosmosvaloper1validator'"},
+		{"cosmos1wallet", "transfer.recipient = 'cosmos1wallet'"},
+		{"cosmos1wallet", "fungible_token_packet.receiver = 'cosmos1wallet'"},
+	}
+
+	for _, test := range tests {
+		found := false
+		for _, query := range queries {
+			if query.Name == test.name && query.Query == test.query {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			t.Errorf("expected query %q named %q", test.query, test.name)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Increased on every backwards incompatible change in the JSON report schema.
+const WebhookSchemaVersion = 1
+
+const WebhookSignatureHeader = "X-Signature-256"
+
+// WebhookTimestampHeader has the same time as the sent_at field, so the receivers
+// can reject the old requests without parsing the body.
+const WebhookTimestampHeader = "X-Webhook-Timestamp"
+
+// WebhookQueueSize is how many reports can wait to be sent to each URL, the new
+// ones are dropped if the URL is down for so long that the queue is full.
+const WebhookQueueSize = 1000
+
+type JSONReport struct {
+	Version  int           `json:"version"`
+	SentAt   int64         `json:"sent_at,omitempty"`
+	Chain    string        `json:"chain"`
+	Tx       JSONTx        `json:"tx"`
+	Messages []JSONMessage `json:"messages"`
+}
+
+type JSONTx struct {
+	Hash       string        `json:"hash"`
+	Height     int64         `json:"height"`
+	Link       string        `json:"link"`
+	Memo       string        `json:"memo"`
+	Success    bool          `json:"success"`
+	Code       uint32        `json:"code"`
+	Codespace  string        `json:"codespace"`
+	Log        string        `json:"log,omitempty"`
+	Fee        []JSONAmount  `json:"fee"`
+	FeePayer   *JSONAddress  `json:"fee_payer,omitempty"`
+	FeeGranter *JSONAddress  `json:"fee_granter,omitempty"`
+	GasUsed    int64         `json:"gas_used"`
+	GasWanted  int64         `json:"gas_wanted"`
+	Signers    []JSONAddress `json:"signers"`
+	Timestamp  *time.Time    `json:"timestamp,omitempty"`
+
+	MatchedQueries []string `json:"matched_queries"`
+}
+
+type JSONMessage struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+type JSONAddress struct {
+	Address string `json:"address"`
+	Label   string `json:"label,omitempty"`
+	Link    string `json:"link"`
+}
+
+type JSONValidator struct {
+	Address string `json:"address"`
+	Moniker string `json:"moniker,omitempty"`
+	Link    string `json:"link"`
+}
+
+type JSONAmount struct {
+	Amount       json.Number  `json:"amount"`
+	Denom        string       `json:"denom"`
+	FiatValue    *json.Number `json:"fiat_value,omitempty"`
+	FiatCurrency string       `json:"fiat_currency,omitempty"`
+	Channel      string       `json:"channel,omitempty"`
+}
+
+func (c *Chain) getJSONAddress(address string) JSONAddress {
+	label, _ := c.LabelsConfigManager.getWalletLabel(address)
+
+	return JSONAddress{
+		Address: address,
+		Label:   label,
+		Link:    c.makeMintscanAccountLink(address),
+	}
+}
+
+func (c *Chain) getJSONValidator(address string) JSONValidator {
+	result := JSONValidator{
+		Address: address,
+		Link:    c.makeMintscanValidatorLink(address),
+	}
+
+	if validator, err := c.CacheManager.getValidatorMaybeFromCache(address); err != nil {
+		log.Warn().Err(err).Str("address", address).Msg("Could not load validator info")
+	} else {
+		result.Moniker = validator.Description.Moniker
+	}
+
+	return result
+}
+
+// getJSONAmount adds the fiat value of the tokens if their price is known.
+func (c *Chain) getJSONAmount(amount sdk.Dec, denom string, baseDenom string) JSONAmount {
+	result := JSONAmount{
+		Amount: json.Number(amount.String()),
+		Denom:  denom,
+	}
+
+	if price, found := c.PriceManager.getPrice(baseDenom); found {
+		fiatValue := json.Number(getFiatValue(amount, price).String())
+		result.FiatValue = &fiatValue
+		result.FiatCurrency = c.FiatCurrency
+	}
+
+	return result
+}
+
+func (c *Chain) getJSONCoin(coin Coin) JSONAmount {
+	result := c.getJSONAmount(coin.Amount, coin.Denom, coin.BaseDenom)
+	result.Channel = coin.Channel
+	return result
+}
+
+func (c *Chain) getJSONCoins(coins []Coin) []JSONAmount {
+	amounts := make([]JSONAmount, len(coins))
+	for index, coin := range coins {
+		amounts[index] = c.getJSONCoin(coin)
+	}
+
+	return amounts
+}
+
+// getJSONDecCoins converts the rewards or commission, which are in base denom.
+func (c *Chain) getJSONDecCoins(coins sdk.DecCoins) []JSONAmount {
+	return c.getJSONCoins(c.getDecCoins(coins))
+}
+
+func (tx Tx) SerializeJSON(chain *Chain) JSONTx {
+	result := JSONTx{
+		Hash:      tx.Hash,
+		Height:    tx.Height,
+		Link:      chain.makeMintscanTxLink(tx.Hash),
+		Memo:      tx.Memo,
+		Success:   !tx.Failed(),
+		Code:      tx.Code,
+		Codespace: tx.Codespace,
+		Fee:       chain.getJSONCoins(tx.Fee),
+		GasUsed:   tx.GasUsed,
+		GasWanted: tx.GasWanted,
+		Signers:   []JSONAddress{},
+
+		MatchedQueries: tx.MatchedQueries,
+	}
+
+	if tx.Failed() {
+		result.Log = tx.Log
+	}
+
+	if tx.FeePayer != "" {
+		feePayer := chain.getJSONAddress(tx.FeePayer)
+		result.FeePayer = &feePayer
+	}
+
+	if tx.FeeGranter != "" {
+		feeGranter := chain.getJSONAddress(tx.FeeGranter)
+		result.FeeGranter = &feeGranter
+	}
+
+	for _, signer := range tx.Signers {
+		result.Signers = append(result.Signers, chain.getJSONAddress(signer))
+	}
+
+	if !tx.Timestamp.IsZero() {
+		result.Timestamp = &tx.Timestamp
+	}
+
+	return result
+}
+
+func (r Report) SerializeJSON() JSONReport {
+	result := JSONReport{
+		Version:  WebhookSchemaVersion,
+		Chain:    r.Chain.Name,
+		Tx:       r.Tx.SerializeJSON(r.Chain),
+		Messages: []JSONMessage{},
+	}
+
+	for _, msg := range r.Msgs {
+		result.Messages = append(result.Messages, JSONMessage{
+			Type: getMsgTypeName(msg),
+			Data: msg.SerializeJSON(r.Chain),
+		})
+	}
+
+	return result
+}
+
+type WebhookReporter struct {
+	ReporterName    string
+	ReporterQueries []string
+	ReporterFilter  *Filter
+
+	WebhookURLs       []string
+	WebhookSecret     string
+	WebhookMaxRetries int
+
+	WebhookFailedTxsMode FailedTxsMode
+
+	HttpClient *http.Client
+
+	queues map[string]chan JSONReport
+}
+
+func (r WebhookReporter) Serialize(report Report) string {
+	bytes, err := json.Marshal(report.SerializeJSON())
+	if err != nil {
+		log.Error().Err(err).Msg("Could not serialize report to JSON")
+		return ""
+	}
+
+	return string(bytes)
+}
+
+func (r *WebhookReporter) Init() {
+	if len(r.WebhookURLs) == 0 {
+		log.Debug().Msg("Webhook URLs not set, not creating webhook reporter.")
+		return
+	}
+
+	r.HttpClient = &http.Client{Timeout: 10 * time.Second}
+
+	// each URL has its own queue, so the retries to a URL that is down
+	// do not delay the reports to the other ones and to the other reporters
+	r.queues = make(map[string]chan JSONReport, len(r.WebhookURLs))
+	for _, url := range r.WebhookURLs {
+		queue := make(chan JSONReport, WebhookQueueSize)
+		r.queues[url] = queue
+		go r.processQueue(url, queue)
+	}
+}
+
+func (r WebhookReporter) Enabled() bool {
+	return r.HttpClient != nil
+}
+
+// Serializer is not used by this reporter, as reports are sent as JSON.
+func (r WebhookReporter) Serializer() Serializer {
+	return NewPlainSerializer(TxFields{})
+}
+
+// SendReport only queues the report, it's sent in the background.
+func (r WebhookReporter) SendReport(report Report) error {
+	jsonReport := report.SerializeJSON()
+	var lastErr error
+
+	for _, url := range r.WebhookURLs {
+		select {
+		case r.queues[url] <- jsonReport:
+		default:
+			log.Error().Str("url", url).Msg("Webhook queue is full, dropping report")
+			lastErr = fmt.Errorf("webhook queue for %s is full", url)
+		}
+	}
+
+	return lastErr
+}
+
+func (r WebhookReporter) processQueue(url string, queue chan JSONReport) {
+	for report := range queue {
+		if err := r.sendWithRetries(url, report); err != nil {
+			log.Error().Err(err).Str("url", url).Msg("Could not send report to webhook")
+		}
+	}
+}
+
+// sendWithRetries sends the report, retrying with exponential backoff
+// on network errors and non-2xx responses.
+func (r WebhookReporter) sendWithRetries(url string, report JSONReport) error {
+	delay := time.Second
+	var err error
+
+	for attempt := 0; attempt <= r.WebhookMaxRetries; attempt++ {
+		if attempt > 0 {
+			log.Debug().
+				Str("url", url).
+				Int("attempt", attempt).
+				Dur("delay", delay).
+				Msg("Retrying sending report to webhook")
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		if err = r.send(url, report); err == nil {
+			return nil
+		}
+
+		log.Warn().Err(err).Str("url", url).Int("attempt", attempt).Msg("Error sending report to webhook")
+	}
+
+	return err
+}
+
+// send sends the report with the current time, which is signed along with the rest
+// of the body, so the receivers can reject the requests replayed later.
+func (r WebhookReporter) send(url string, report JSONReport) error {
+	report.SentAt = time.Now().Unix()
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(WebhookTimestampHeader, strconv.FormatInt(report.SentAt, 10))
+
+	if r.WebhookSecret != "" {
+		request.Header.Set(WebhookSignatureHeader, "sha256="+getHMACSignature(r.WebhookSecret, body))
+	}
+
+	response, err := r.HttpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// reading the body so the connection can be reused
+	if _, err := io.Copy(ioutil.Discard, response.Body); err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("got unexpected status code %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+func getHMACSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (r WebhookReporter) Name() string {
+	return r.ReporterName
+}
+
+func (r WebhookReporter) FailedTxsMode() FailedTxsMode {
+	return r.WebhookFailedTxsMode
+}
+
+func (r WebhookReporter) Queries() []string {
+	return r.ReporterQueries
+}
+
+func (r WebhookReporter) Filter() *Filter {
+	return r.ReporterFilter
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGetHMACSignature(t *testing.T) {
+	tests := []struct {
+		secret   string
+		body     string
+		expected string
+	}{
+		// RFC 4231, test case 2
+		{"Jefe", "what do ya want for nothing?", "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"},
+		{"secret", "", "f9e66e179b6747ae54108f82f8ade8b3c25d76fd30afde6c395822c530196169"},
+	}
+
+	for _, test := range tests {
+		result := getHMACSignature(test.secret, []byte(test.body))
+		if result != test.expected {
+			t.Errorf("%q signed with %q: expected %s, got %s", test.body, test.secret, test.expected, result)
+		}
+	}
+
+	if getHMACSignature("secret", []byte("body")) == getHMACSignature("other secret", []byte("body")) {
+		t.Errorf("expected different secrets to give different signatures")
+	}
+}
+
+type webhookTestPriceProvider struct{}
+
+func (p webhookTestPriceProvider) Name() string {
+	return "test"
+}
+
+func (p webhookTestPriceProvider) GetPrice(denom string) (float64, error) {
+	return 10.5, nil
+}
+
+func TestReportSerializeJSON(t *testing.T) {
+	report := getFilterTestReport(getFilterTestSend("150.5"))
+	report.Chain.MintscanProject = "cosmos"
+	report.Chain.FiatCurrency = "usd"
+	report.Chain.LabelsConfigManager = &LabelsConfigManager{
+		config:  LabelsConfig{WalletLabels: map[string]string{"cosmos1receiver": "exchange"}},
+		enabled: true,
+	}
+	report.Chain.PriceManager = &PriceManager{
+		chain:     "cosmos",
+		providers: map[string]PriceProvider{getPriceKey("uatom"): webhookTestPriceProvider{}},
+	}
+
+	bytes, err := json.Marshal(report.SerializeJSON())
+	if err != nil {
+		t.Fatalf("could not serialize report: %s", err)
+	}
+
+	// the receivers depend on this, so changing it means increasing WebhookSchemaVersion
+	expected := `{"version":1,"chain":"cosmos",` +
+		`"tx":{"hash":"ABCDEF0123456789","height":100,"link":"https://mintscan.io/cosmos/txs/ABCDEF0123456789",` +
+		`"memo":"Claim your airdrop at \u003ca href='x'\u003e","success":true,"code":0,"codespace":"","fee":[],` +
+		`"gas_used":0,"gas_wanted":0,` +
+		`"signers":[{"address":"cosmos1sender","link":"https://mintscan.io/cosmos/account/cosmos1sender"}],` +
+		`"matched_queries":["transfers"]},` +
+		`"messages":[{"type":"MsgSend","data":{` +
+		`"from":{"address":"cosmos1sender","link":"https://mintscan.io/cosmos/account/cosmos1sender"},` +
+		`"to":{"address":"cosmos1receiver","label":"exchange","link":"https://mintscan.io/cosmos/account/cosmos1receiver"},` +
+		`"amount":[{"amount":150.500000000000000000,"denom":"atom","fiat_value":1580.250000000000000000,"fiat_currency":"usd"}]}}]}`
+
+	if string(bytes) != expected {
+		t.Errorf("expected %s, got %s", expected, string(bytes))
+	}
+}